@@ -1,6 +1,9 @@
 package cmd
 
 import (
+	"log"
+
+	"github.com/breadtubetv/bake/util"
 	"github.com/spf13/cobra"
 )
 
@@ -17,3 +20,18 @@ var channelCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(channelCmd)
 }
+
+// loadChannels loads the channels in dataDir, logging every file that couldn't
+// be loaded and carrying on without it. Those files are returned as well, and
+// it exits if the folder can't be read at all.
+func loadChannels(dataDir string) (util.ChannelList, []error) {
+	channels, errs := util.LoadChannels(dataDir)
+	if channels == nil {
+		log.Fatalf("Failed to load channels from %s: %v", dataDir, errs[0])
+	}
+
+	for _, err := range errs {
+		log.Printf("Skipping channel %v", err)
+	}
+	return channels, errs
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"log"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -18,11 +19,9 @@ var configCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		var provider = args[0]
 
-		if _, ok := Providers[provider]; !ok {
-			panic(fmt.Sprintf("No provider existed called %s", provider))
+		if err := getProvider(provider).Configure(); err != nil {
+			log.Fatalf("Failed to configure %s: %v", provider, err)
 		}
-
-		Providers[provider]["config"].(func())()
 	},
 }
 
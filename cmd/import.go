@@ -6,6 +6,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/breadtubetv/bake/providers"
 	"github.com/breadtubetv/bake/util"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -13,28 +14,34 @@ import (
 
 // importCmd represents the import command
 var importCmd = &cobra.Command{
-	Use:   "import <slug> <provider> <channel_url>",
+	Use:   "import <slug> [provider] <channel_url>",
 	Short: "Import a channel into BreadtubeTV",
 	Long: fmt.Sprintf(`Add the supplied channel into BreadtubeTV, without having to edit JSON.
 
+	The provider can be left out when it can be told from the URL, and the URL
+	has to belong to the provider when one is given.
+
 	Available providers: %s`, strings.Join(ProviderNames(), ", ")),
-	Args: cobra.ExactArgs(3),
+	Args: cobra.RangeArgs(2, 3),
 	Run: func(cmd *cobra.Command, args []string) {
 		var slug = args[0]
-		var provider = args[1]
-		var channelURL, err = util.ParseURL(args[2])
+		var name, rawURL = "", args[1]
+		if len(args) == 3 {
+			name, rawURL = args[1], args[2]
+		}
+		var channelURL, err = util.ParseURL(rawURL)
 		projectRoot := os.ExpandEnv(viper.GetString("projectRoot"))
 
 		if err != nil {
-			log.Fatalf("Improperly formatted URL provided '%s': %v", args[1], err)
-		}
-
-		if _, ok := Providers[provider]; !ok {
-			log.Fatalf("No provider exists called %s", provider)
+			log.Fatalf("Improperly formatted URL provided '%s': %v", rawURL, err)
 		}
+		name, provider := providerForURL(name, channelURL)
 
 		log.Printf("Importing %s...\n", channelURL)
-		Providers[provider]["channel_import"].(func(string, *util.URL, string))(slug, channelURL, projectRoot)
+		err = providers.ImportChannel(name, provider, slug, channelURL, projectRoot)
+		if err != nil {
+			log.Fatalf("Failed to import channel %s: %v", slug, err)
+		}
 	},
 }
 
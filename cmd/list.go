@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/breadtubetv/bake/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	listTags     []string
+	listProvider string
+	listSort     string
+	listOutput   string
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the channels in the project",
+	Long: `List every channel with its providers, subscriber counts and tags.
+
+	Channels can be narrowed down with --tag, which may be given more than once,
+	and --provider. Sorting by subscribers puts the most subscribed channels
+	first, counting only the --provider's subscribers when one is given.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		projectRoot := os.ExpandEnv(viper.GetString("projectRoot"))
+		channels, _ := loadChannels(path.Join(projectRoot, "/data/channels"))
+
+		listed := filterChannels(channels)
+		if err := sortChannels(listed); err != nil {
+			log.Fatal(err)
+		}
+
+		var err error
+		switch listOutput {
+		case "table":
+			err = printChannelTable(listed)
+		case "json":
+			err = printChannelJSON(listed)
+		case "csv":
+			err = printChannelCSV(listed)
+		default:
+			err = fmt.Errorf("unknown output format '%s', use table, json or csv", listOutput)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	channelCmd.AddCommand(listCmd)
+
+	listCmd.Flags().StringSliceVar(&listTags, "tag", nil, "only list channels with this tag")
+	listCmd.Flags().StringVar(&listProvider, "provider", "", "only list channels on this provider")
+	listCmd.Flags().StringVar(&listSort, "sort", "slug", "sort by slug, name or subscribers")
+	listCmd.Flags().StringVarP(&listOutput, "output", "o", "table", "output format, one of table, json or csv")
+}
+
+// channelSummary is a single row of `bake channel list`
+type channelSummary struct {
+	Slug        string            `json:"slug"`
+	Name        string            `json:"name"`
+	Providers   []string          `json:"providers"`
+	Subscribers map[string]uint64 `json:"subscribers"`
+	Tags        []string          `json:"tags"`
+}
+
+// filterChannels returns the channels matching every --tag and --provider
+func filterChannels(channels util.ChannelList) []*util.Channel {
+	var listed []*util.Channel
+	for _, slug := range sortedSlugs(channels) {
+		channel, _ := channels.Find(slug)
+
+		if listProvider != "" {
+			if _, ok := channel.Providers[listProvider]; !ok {
+				continue
+			}
+		}
+
+		matches := true
+		for _, tag := range listTags {
+			if !channel.HasTag(tag) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			listed = append(listed, channel)
+		}
+	}
+	return listed
+}
+
+// sortChannels orders the channels by --sort, they are already in slug order
+func sortChannels(channels []*util.Channel) error {
+	switch listSort {
+	case "slug":
+	case "name":
+		sort.SliceStable(channels, func(i, j int) bool {
+			return strings.ToLower(channels[i].Name) < strings.ToLower(channels[j].Name)
+		})
+	case "subscribers":
+		sort.SliceStable(channels, func(i, j int) bool {
+			return subscriberCount(channels[i]) > subscriberCount(channels[j])
+		})
+	default:
+		return fmt.Errorf("unknown sort order '%s', use slug, name or subscribers", listSort)
+	}
+	return nil
+}
+
+// subscriberCount totals the channel's subscribers, on --provider alone when
+// it is set
+func subscriberCount(channel *util.Channel) uint64 {
+	if listProvider != "" {
+		return channel.Providers[listProvider].Subscribers
+	}
+
+	var total uint64
+	for _, provider := range channel.Providers {
+		total += provider.Subscribers
+	}
+	return total
+}
+
+func summarise(channel *util.Channel) channelSummary {
+	summary := channelSummary{
+		Slug:        channel.Slug,
+		Name:        channel.Name,
+		Providers:   channel.ProviderNames(),
+		Subscribers: make(map[string]uint64),
+		Tags:        channel.Tags,
+	}
+	for name, provider := range channel.Providers {
+		summary.Subscribers[name] = provider.Subscribers
+	}
+	return summary
+}
+
+// formatSubscribers lists the subscribers on each provider, e.g.
+// youtube=1200;patreon=40
+func (s channelSummary) formatSubscribers(separator string) string {
+	counts := make([]string, 0, len(s.Providers))
+	for _, name := range s.Providers {
+		counts = append(counts, fmt.Sprintf("%s=%d", name, s.Subscribers[name]))
+	}
+	return strings.Join(counts, separator)
+}
+
+func printChannelTable(channels []*util.Channel) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SLUG\tNAME\tPROVIDERS\tSUBSCRIBERS\tTAGS")
+	for _, channel := range channels {
+		summary := summarise(channel)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			summary.Slug,
+			summary.Name,
+			strings.Join(summary.Providers, ", "),
+			summary.formatSubscribers(", "),
+			strings.Join(summary.Tags, ", "),
+		)
+	}
+	return w.Flush()
+}
+
+func printChannelJSON(channels []*util.Channel) error {
+	summaries := make([]channelSummary, 0, len(channels))
+	for _, channel := range channels {
+		summaries = append(summaries, summarise(channel))
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(summaries)
+}
+
+func printChannelCSV(channels []*util.Channel) error {
+	w := csv.NewWriter(os.Stdout)
+	w.Write([]string{"slug", "name", "providers", "subscribers", "tags"})
+	for _, channel := range channels {
+		summary := summarise(channel)
+		w.Write([]string{
+			summary.Slug,
+			summary.Name,
+			strings.Join(summary.Providers, ";"),
+			summary.formatSubscribers(";"),
+			strings.Join(summary.Tags, ";"),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
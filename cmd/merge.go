@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"log"
+	"os"
+
+	"github.com/breadtubetv/bake/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var mergeAlias bool
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge <keep_slug> <drop_slug>",
+	Short: "Merge a duplicate channel into another",
+	Long: `Merge the channel drop_slug into keep_slug, combining their providers, tags and
+	other fields and moving drop_slug's videos across, then delete drop_slug.
+
+	Where both channels have the same field, or the same field of a provider,
+	keep_slug's is kept, and the videos of a provider both have are combined.
+	drop_slug's aliases are always kept, and with --alias so is its permalink.
+	Nothing is changed if the same video has different details in each channel.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		projectRoot := os.ExpandEnv(viper.GetString("projectRoot"))
+
+		err := util.MergeChannels(args[0], args[1], projectRoot, mergeAlias)
+		if err != nil {
+			log.Fatalf("Failed to merge channel %s into %s: %v", args[1], args[0], err)
+		}
+	},
+}
+
+func init() {
+	channelCmd.AddCommand(mergeCmd)
+
+	mergeCmd.Flags().BoolVar(&mergeAlias, "alias", false, "keep the dropped channel's permalink as an alias so the site redirects it")
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/breadtubetv/bake/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var migrateDryRun bool
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate [kinds...]",
+	Short: "Upgrade data files to the current format",
+	Long: fmt.Sprintf(`Upgrade data files to the current version of their format, recorded in the
+	schema_version field of every file. Only the given kinds of data file are
+	upgraded, or all of them when none are given.
+
+	Kinds of data file: %s
+
+	With --dry-run nothing is saved, and the changes are shown as a diff instead.`, strings.Join(util.Migrations.Kinds(), ", ")),
+	ValidArgs: util.Migrations.Kinds(),
+	Args:      cobra.OnlyValidArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		projectRoot := os.ExpandEnv(viper.GetString("projectRoot"))
+
+		kinds := args
+		if len(kinds) == 0 {
+			kinds = util.Migrations.Kinds()
+		}
+
+		ok := true
+		for _, kind := range kinds {
+			if !migrate(kind, projectRoot) {
+				ok = false
+			}
+		}
+		if !ok {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+
+	migrateCmd.Flags().BoolVar(&migrateDryRun, "dry-run", false, "show the changes as a diff instead of saving them")
+}
+
+// migrate upgrades every file of a kind, returning false if any failed
+func migrate(kind, projectRoot string) bool {
+	files, err := util.Migrations.Files(kind, projectRoot)
+	if err != nil {
+		log.Printf("Failed to find %s: %v", kind, err)
+		return false
+	}
+
+	var upgraded, failed int
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			log.Printf("Failed to read %s: %v", file, err)
+			failed++
+			continue
+		}
+
+		migrated, applied, err := util.Migrations.Migrate(kind, data)
+		if err != nil {
+			log.Printf("Failed to upgrade %s: %v", file, err)
+			failed++
+			continue
+		}
+		if string(migrated) == string(data) {
+			continue
+		}
+		upgraded++
+
+		if migrateDryRun {
+			for _, description := range applied {
+				fmt.Printf("# %s\n", description)
+			}
+			fmt.Print(util.Diff(file, data, migrated))
+			continue
+		}
+
+		log.Printf("Saving %s\n", file)
+		if err := util.WriteFile(file, migrated); err != nil {
+			log.Printf("Failed to save %s: %v", file, err)
+			failed++
+			upgraded--
+		}
+	}
+
+	verb := "upgraded"
+	if migrateDryRun {
+		verb = "to upgrade"
+	}
+	fmt.Printf("%s: %d %s to version %d, %d already current, %d failed\n",
+		kind, upgraded, verb, util.Migrations.Version(kind), len(files)-upgraded-failed, failed)
+	return failed == 0
+}
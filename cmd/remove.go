@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/breadtubetv/bake/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	removeDryRun     bool
+	removeKeepVideos bool
+)
+
+var removeCmd = &cobra.Command{
+	Use:   "remove <slug>",
+	Short: "Remove a channel and everything saved for it",
+	Long: `Remove a channel's file along with its videos folder and profile image.
+
+	A channel that other data, such as a playlist, still refers to in a creator,
+	creators, channel, channels or aliases field is not removed, update or delete
+	that data first. If any of the channel's files can't be deleted, none are.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		slug := args[0]
+		projectRoot := os.ExpandEnv(viper.GetString("projectRoot"))
+		dataDir := path.Join(projectRoot, "/data/channels")
+
+		if channels, _ := loadChannels(dataDir); !channels.Contains(slug) {
+			log.Fatalf("couldn't find channel with slug '%s'", slug)
+		}
+
+		references, err := util.FindReferences(slug, projectRoot)
+		if err != nil {
+			log.Fatalf("Failed to check for references to %s: %v", slug, err)
+		}
+		if len(references) > 0 {
+			log.Fatalf("Not removing %s, it is still referenced by:\n  %s", slug, strings.Join(references, "\n  "))
+		}
+
+		if removeDryRun {
+			targets, err := util.ChannelPaths(slug, projectRoot, removeKeepVideos)
+			if err != nil {
+				log.Fatalf("Failed to find what to remove for %s: %v", slug, err)
+			}
+			for _, target := range targets {
+				fmt.Printf("Would remove %s\n", target)
+			}
+			return
+		}
+
+		if err := util.RemoveChannel(slug, projectRoot, removeKeepVideos); err != nil {
+			log.Fatalf("Failed to remove %s: %v", slug, err)
+		}
+	},
+}
+
+func init() {
+	channelCmd.AddCommand(removeCmd)
+
+	removeCmd.Flags().BoolVar(&removeDryRun, "dry-run", false, "list what would be removed without removing anything")
+	removeCmd.Flags().BoolVar(&removeKeepVideos, "keep-videos", false, "leave the channel's videos folder in place")
+}
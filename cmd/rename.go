@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"log"
+	"os"
+
+	"github.com/breadtubetv/bake/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var renameAlias bool
+
+var renameCmd = &cobra.Command{
+	Use:   "rename <old_slug> <new_slug>",
+	Short: "Change the slug of a channel",
+	Long: `Change the slug of a channel, moving its file, videos folder and profile image,
+	and updating its permalink and the channel of each of its videos.
+
+	A channel that other data, such as a playlist, still refers to in a creator,
+	creators, channel, channels or aliases field is not renamed, update that data
+	first. If any part of the rename fails, everything is put back the way it was.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		projectRoot := os.ExpandEnv(viper.GetString("projectRoot"))
+
+		err := util.RenameChannel(args[0], args[1], projectRoot, renameAlias)
+		if err != nil {
+			log.Fatalf("Failed to rename channel %s: %v", args[0], err)
+		}
+	},
+}
+
+func init() {
+	channelCmd.AddCommand(renameCmd)
+
+	renameCmd.Flags().BoolVar(&renameAlias, "alias", false, "keep the old permalink as an alias so the site redirects it")
+}
@@ -2,31 +2,79 @@ package cmd
 
 import (
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/breadtubetv/bake/providers"
+	"github.com/breadtubetv/bake/util"
 
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
-var cfgFile string
+var (
+	cfgFile     string
+	recordDir   string
+	replayDir   string
+	quotaBudget int
+)
 
-//Providers is a map setup so that we can call functions dynamically for providers.
-// e.g. Providers["youtube"]["config"].(func(string))("somestring")
-var Providers = map[string]map[string]interface{}{
-	"youtube": providers.LoadYoutube(),
+// Providers is the registry every command dispatches provider calls through.
+// e.g. Providers.Get("youtube")
+var Providers = loadProviders()
+
+// youTube is kept so --record and --replay can swap its HTTP client
+var youTube *providers.YouTubeProvider
+
+func loadProviders() *providers.Registry {
+	registry := providers.NewRegistry()
+	youTube = providers.LoadYoutube(registry)
+	providers.LoadPatreon(registry)
+	providers.LoadVimeo(registry)
+	// PeerTube can only match URLs by path, so it is registered last and
+	// providerForURL tries the others first
+	providers.LoadPeerTube(registry)
+	return registry
 }
 
 // ProviderNames gets all available providers
 func ProviderNames() []string {
-	keys := make([]string, 0, len(Providers))
-	for k := range Providers {
-		keys = append(keys, k)
+	return Providers.Names()
+}
+
+// getProvider returns the named provider, exiting with the list of available
+// providers if there is no such provider
+func getProvider(name string) providers.Provider {
+	provider, ok := Providers.Get(name)
+	if !ok {
+		log.Fatalf("No provider exists called '%s', available providers: %s", name, strings.Join(ProviderNames(), ", "))
+	}
+	return provider
+}
+
+// providerForURL returns the provider called name, or the provider the URL
+// belongs to when name is empty. It exits if the URL doesn't belong to the
+// named provider, or to any provider when none is named.
+func providerForURL(name string, u *util.URL) (string, providers.Provider) {
+	matched, matchedProvider, ok := Providers.Match(u)
+	if name == "" {
+		if !ok {
+			log.Fatalf("Couldn't tell the provider of %s, give one of: %s", u, strings.Join(ProviderNames(), ", "))
+		}
+		return matched, matchedProvider
+	}
+
+	provider := getProvider(name)
+	if !provider.MatchesURL(u) {
+		if ok {
+			log.Fatalf("%s isn't a %s URL, it looks like a %s URL", u, name, matched)
+		}
+		log.Fatalf("%s isn't a %s URL", u, name)
 	}
-	return keys
+	return name, provider
 }
 
 // rootCmd represents the base command when called without any subcommands
@@ -36,7 +84,11 @@ var rootCmd = &cobra.Command{
 	Long: `Bake can be used to manage the content available in BreadtubeTV.
 
 	You can add channels, playlists, videos and courses without having to edit data directly.
-	`}
+	`,
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		printQuotaSummary()
+	},
+}
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
@@ -56,6 +108,9 @@ func init() {
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.bake.yaml)")
+	rootCmd.PersistentFlags().StringVar(&recordDir, "record", "", "save every YouTube API response as a fixture in this directory")
+	rootCmd.PersistentFlags().StringVar(&replayDir, "replay", "", "serve YouTube API responses from the fixtures in this directory instead of the network")
+	rootCmd.PersistentFlags().IntVar(&quotaBudget, "quota-budget", 0, "stop making YouTube API calls once this many quota units are used (default unlimited)")
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -89,4 +144,31 @@ func initConfig() {
 	if err := viper.ReadInConfig(); err == nil {
 		fmt.Println("Using config file:", viper.ConfigFileUsed())
 	}
+
+	initRecording()
+	youTube.Quota.Budget = quotaBudget
+}
+
+// initRecording points the YouTube provider at the fixture directory given by
+// --record or --replay
+func initRecording() {
+	switch {
+	case recordDir != "" && replayDir != "":
+		log.Fatal("both --record and --replay provided, expected only one")
+	case recordDir != "":
+		log.Printf("Recording YouTube API responses to %s", recordDir)
+		if err := youTube.Record(recordDir); err != nil {
+			log.Fatalf("Unable to record YouTube API responses: %v", err)
+		}
+	case replayDir != "":
+		log.Printf("Replaying YouTube API responses from %s", replayDir)
+		youTube.Replay(replayDir)
+	}
+}
+
+// printQuotaSummary reports the YouTube quota used by the command, if any
+func printQuotaSummary() {
+	if youTube.Quota.Used() > 0 || youTube.Quota.Exhausted() {
+		fmt.Print(youTube.Quota.Summary())
+	}
 }
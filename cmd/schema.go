@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/breadtubetv/bake/providers"
+	"github.com/breadtubetv/bake/util"
+	"github.com/spf13/cobra"
+)
+
+var schemaDir string
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Work with the JSON Schema of the data files",
+}
+
+var schemaExportCmd = &cobra.Command{
+	Use:   "export [kinds...]",
+	Short: "Export the JSON Schema of data files",
+	Long: fmt.Sprintf(`Export the JSON Schema of the given kinds of data file, generated from the
+	types bake reads them into. Editors can use them to complete and check the
+	files as they are edited, and bake validate checks the files against them.
+
+	Kinds of data file: %s
+
+	A single kind is printed, with --dir each kind is saved as <kind>.schema.json
+	in the folder, every kind when none are given.`, strings.Join(schemaKinds(), ", ")),
+	ValidArgs: schemaKinds(),
+	Args:      cobra.OnlyValidArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		kinds := args
+		if len(kinds) == 0 {
+			kinds = schemaKinds()
+		}
+
+		if schemaDir == "" {
+			if len(kinds) != 1 {
+				log.Fatalf("Give a single kind to print, or --dir to save %s", strings.Join(kinds, ", "))
+			}
+			data := marshalSchema(kinds[0])
+			fmt.Print(string(data))
+			return
+		}
+
+		if err := os.MkdirAll(schemaDir, 0755); err != nil {
+			log.Fatalf("Failed to create %s: %v", schemaDir, err)
+		}
+		for _, kind := range kinds {
+			file := path.Join(schemaDir, kind+".schema.json")
+			log.Printf("Saving %s\n", file)
+			if err := util.WriteFile(file, marshalSchema(kind)); err != nil {
+				log.Fatalf("Failed to save %s: %v", file, err)
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+	schemaCmd.AddCommand(schemaExportCmd)
+
+	schemaExportCmd.Flags().StringVar(&schemaDir, "dir", "", "folder to save the schemas in")
+}
+
+// schemas holds the schema of each kind of data file, keyed like
+// util.Migrations
+var schemas = map[string]*util.Schema{
+	"channels": util.GenerateSchema(util.Channel{}),
+	"videos":   util.GenerateSchema(providers.Video{}),
+}
+
+// schemaKinds returns the kinds of data file that have a schema
+func schemaKinds() []string {
+	var kinds []string
+	for kind := range schemas {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+func marshalSchema(kind string) []byte {
+	data, err := json.MarshalIndent(schemas[kind], "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to export the %s schema: %v", kind, err)
+	}
+	return append(data, '\n')
+}
@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/breadtubetv/bake/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var showOutput string
+
+var showCmd = &cobra.Command{
+	Use:   "show <slug>",
+	Short: "Show everything bake knows about a channel",
+	Long: `Show every field of a channel, including each of its providers and any extra
+	fields in its file, along with how many videos it has and whether its profile
+	image has been saved.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		projectRoot := os.ExpandEnv(viper.GetString("projectRoot"))
+		channels, _ := loadChannels(path.Join(projectRoot, "/data/channels"))
+
+		channel, ok := channels.Find(args[0])
+		if !ok {
+			log.Fatalf("couldn't find channel with slug '%s'", args[0])
+		}
+		details := describeChannel(channel, projectRoot)
+
+		var err error
+		switch showOutput {
+		case "text":
+			err = printChannelDetails(details)
+		case "json":
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			err = encoder.Encode(details)
+		default:
+			err = fmt.Errorf("unknown output format '%s', use text or json", showOutput)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	channelCmd.AddCommand(showCmd)
+
+	showCmd.Flags().StringVarP(&showOutput, "output", "o", "text", "output format, one of text or json")
+}
+
+// channelDetails is everything `bake channel show` reports about a channel
+type channelDetails struct {
+	SchemaVersion int                        `json:"schema_version"`
+	Slug          string                     `json:"slug"`
+	Name          string                     `json:"name"`
+	Permalink     string                     `json:"permalink"`
+	Aliases       []string                   `json:"aliases"`
+	Tags          []string                   `json:"tags"`
+	Providers     map[string]providerDetails `json:"providers"`
+	Extra         map[string]interface{}     `json:"extra"`
+	VideoCount    int                        `json:"video_count"`
+	Image         string                     `json:"image"`
+	ImageExists   bool                       `json:"image_exists"`
+}
+
+// providerDetails is a util.Provider as it is shown to the user
+type providerDetails struct {
+	Name        string   `json:"name"`
+	Slug        string   `json:"slug"`
+	URL         string   `json:"url"`
+	Description string   `json:"description"`
+	Subscribers uint64   `json:"subscribers"`
+	Videos      []string `json:"videos"`
+}
+
+func describeChannel(channel *util.Channel, projectRoot string) channelDetails {
+	details := channelDetails{
+		SchemaVersion: channel.SchemaVersion,
+		Slug:          channel.Slug,
+		Name:          channel.Name,
+		Permalink:     channel.Permalink,
+		Aliases:       channel.Aliases,
+		Tags:          channel.Tags,
+		Providers:     make(map[string]providerDetails),
+		Extra:         make(map[string]interface{}),
+		Image:         util.ChannelImagePath(channel.Slug, projectRoot),
+	}
+
+	for name, provider := range channel.Providers {
+		entry := providerDetails{
+			Name:        provider.Name,
+			Slug:        provider.Slug,
+			Description: provider.Description,
+			Subscribers: provider.Subscribers,
+			Videos:      provider.Videos,
+		}
+		if provider.URL != nil {
+			entry.URL = provider.URL.String()
+		}
+		details.Providers[name] = entry
+	}
+
+	for key, value := range channel.Extra() {
+		details.Extra[key] = jsonValue(value)
+	}
+
+	// A creator without a videos folder has no videos
+	videos, _ := util.GetCreatorVideos(channel.Slug, projectRoot)
+	details.VideoCount = len(videos)
+
+	if _, err := os.Stat(details.Image); err == nil {
+		details.ImageExists = true
+	}
+
+	return details
+}
+
+// jsonValue converts the maps yaml decodes nested values into, which are keyed
+// on interface{}, into maps encoding/json can handle
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			converted[fmt.Sprint(key)] = jsonValue(item)
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, item := range v {
+			converted[i] = jsonValue(item)
+		}
+		return converted
+	default:
+		return v
+	}
+}
+
+func printChannelDetails(details channelDetails) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", details.Name)
+	fmt.Fprintf(w, "Slug:\t%s\n", details.Slug)
+	fmt.Fprintf(w, "Permalink:\t%s\n", details.Permalink)
+	fmt.Fprintf(w, "Aliases:\t%s\n", strings.Join(details.Aliases, ", "))
+	fmt.Fprintf(w, "Tags:\t%s\n", strings.Join(details.Tags, ", "))
+	if details.SchemaVersion > 0 {
+		fmt.Fprintf(w, "Schema version:\t%d\n", details.SchemaVersion)
+	} else {
+		fmt.Fprintf(w, "Schema version:\t1 (not recorded)\n")
+	}
+	fmt.Fprintf(w, "Videos:\t%d\n", details.VideoCount)
+	if details.ImageExists {
+		fmt.Fprintf(w, "Image:\t%s\n", details.Image)
+	} else {
+		fmt.Fprintf(w, "Image:\t%s (missing)\n", details.Image)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(details.Providers))
+	for name := range details.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		provider := details.Providers[name]
+		fmt.Printf("\nProvider %s:\n", name)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "  Name:\t%s\n", provider.Name)
+		fmt.Fprintf(w, "  Slug:\t%s\n", provider.Slug)
+		fmt.Fprintf(w, "  URL:\t%s\n", provider.URL)
+		fmt.Fprintf(w, "  Description:\t%s\n", firstLine(provider.Description))
+		fmt.Fprintf(w, "  Subscribers:\t%d\n", provider.Subscribers)
+		fmt.Fprintf(w, "  Videos listed:\t%d\n", len(provider.Videos))
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if len(details.Extra) > 0 {
+		// The extra fields can be nested, so they are shown as they are in the file
+		data, err := yaml.Marshal(details.Extra)
+		if err != nil {
+			return err
+		}
+		fmt.Println("\nOther fields:")
+		for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+
+	return nil
+}
+
+// firstLine shortens multi-line descriptions to fit the text output
+func firstLine(text string) string {
+	if i := strings.Index(text, "\n"); i >= 0 {
+		return text[:i] + " ..."
+	}
+	return text
+}
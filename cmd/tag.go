@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path"
+
+	"github.com/breadtubetv/bake/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// tagCmd groups the commands that edit a channel's tags
+var tagCmd = &cobra.Command{
+	Use:   "tag",
+	Short: "Manage the tags of a channel",
+	Long:  `Add, remove or list the tags of a channel. Tags are always saved in lowercase.`,
+}
+
+var tagAddCmd = &cobra.Command{
+	Use:   "add <slug> <tags...>",
+	Short: "Add tags to a channel",
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		editTags(args[0], func(channel *util.Channel) bool {
+			changed := false
+			for _, tag := range args[1:] {
+				if channel.AddTag(tag) {
+					changed = true
+				} else {
+					log.Printf("%s is already tagged '%s'", channel.Slug, util.NormalizeTag(tag))
+				}
+			}
+			return changed
+		})
+	},
+}
+
+var tagRemoveCmd = &cobra.Command{
+	Use:   "remove <slug> <tags...>",
+	Short: "Remove tags from a channel",
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		editTags(args[0], func(channel *util.Channel) bool {
+			changed := false
+			for _, tag := range args[1:] {
+				if channel.RemoveTag(tag) {
+					changed = true
+				} else {
+					log.Printf("%s isn't tagged '%s'", channel.Slug, util.NormalizeTag(tag))
+				}
+			}
+			return changed
+		})
+	},
+}
+
+var tagListCmd = &cobra.Command{
+	Use:   "list <slug>",
+	Short: "List the tags of a channel",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		projectRoot := os.ExpandEnv(viper.GetString("projectRoot"))
+		channels, _ := loadChannels(path.Join(projectRoot, "/data/channels"))
+
+		channel, ok := channels.Find(args[0])
+		if !ok {
+			log.Fatalf("couldn't find channel with slug '%s'", args[0])
+		}
+		for _, tag := range channel.Tags {
+			fmt.Println(tag)
+		}
+	},
+}
+
+func init() {
+	channelCmd.AddCommand(tagCmd)
+	tagCmd.AddCommand(tagAddCmd)
+	tagCmd.AddCommand(tagRemoveCmd)
+	tagCmd.AddCommand(tagListCmd)
+}
+
+// editTags applies edit to the channel, saving it if edit reports a change
+func editTags(slug string, edit func(channel *util.Channel) bool) {
+	projectRoot := os.ExpandEnv(viper.GetString("projectRoot"))
+	dataDir := path.Join(projectRoot, "/data/channels")
+
+	channels, _ := loadChannels(dataDir)
+	channel, ok := channels.Find(slug)
+	if !ok {
+		log.Fatalf("couldn't find channel with slug '%s'", slug)
+	}
+
+	if !edit(channel) {
+		return
+	}
+
+	if err := util.SaveChannel(channel, dataDir); err != nil {
+		log.Fatalf("Failed to save channel %s: %v", slug, err)
+	}
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var tagsCmd = &cobra.Command{
+	Use:   "tags",
+	Short: "List every tag used by the channels",
+	Long:  `List every tag used by the channels, with how many channels have it, most used first.`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		projectRoot := os.ExpandEnv(viper.GetString("projectRoot"))
+		channels, _ := loadChannels(path.Join(projectRoot, "/data/channels"))
+		counts := channels.TagCounts()
+
+		tags := make([]string, 0, len(counts))
+		for tag := range counts {
+			tags = append(tags, tag)
+		}
+		sort.Slice(tags, func(i, j int) bool {
+			if counts[tags[i]] != counts[tags[j]] {
+				return counts[tags[i]] > counts[tags[j]]
+			}
+			return tags[i] < tags[j]
+		})
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TAG\tCHANNELS")
+		for _, tag := range tags {
+			fmt.Fprintf(w, "%s\t%d\n", tag, counts[tag])
+		}
+		w.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tagsCmd)
+}
@@ -2,98 +2,430 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"os/signal"
 	"path"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/breadtubetv/bake/providers"
 	"github.com/breadtubetv/bake/util"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	yaml "gopkg.in/yaml.v2"
 )
 
+var (
+	updateConcurrency int
+	updateResume      bool
+	updateFull        bool
+)
+
+// checkpointFile is where the progress of `bake channel update` is kept,
+// relative to the project root
+const checkpointFile = ".bake-update-checkpoint.yml"
+
 var updateCmd = &cobra.Command{
 	Use:   "update [channel slugs...]",
 	Short: "Refresh all channel files",
-	Long:  fmt.Sprintf(`Refresh all channels with the most current information from their respective providers.`),
-	Args:  cobra.ArbitraryArgs,
+	Long: fmt.Sprintf(`Refresh all channels with the most current information from their respective providers.
+
+	When channel slugs are given, the videos of those channels are imported as well.
+
+	Only uploads newer than the videos bake already knows about are listed and
+	imported, use --full to list every upload and re-import all of them.
+
+	Progress is saved as channels are refreshed, if the update is interrupted
+	run it again with --resume to carry on where it stopped.`),
+	Args: cobra.ArbitraryArgs,
 	Run: func(cmd *cobra.Command, args []string) {
+		projectRoot := os.ExpandEnv(viper.GetString("projectRoot"))
+		dataDir := path.Join(projectRoot, "/data/channels")
+		channels, errs := loadChannels(dataDir)
+
+		var targets []*util.Channel
+		var missing []channelUpdate
 		if len(args) == 0 {
 			log.Println("Updating channels...")
-			updateChannels()
+			for _, slug := range sortedSlugs(channels) {
+				channel, _ := channels.Find(slug)
+				targets = append(targets, channel)
+			}
 		} else {
 			log.Printf("Updating channels %s...\n", args)
-			updateChannelList(args)
+			for _, channelSlug := range args {
+				channel, ok := channels.Find(channelSlug)
+				if !ok {
+					missing = append(missing, channelUpdate{
+						channel: &util.Channel{Slug: channelSlug},
+						err:     fmt.Errorf("couldn't find channel with slug '%s'", channelSlug),
+					})
+					continue
+				}
+				targets = append(targets, channel)
+			}
+		}
+
+		checkpoint := loadCheckpoint(path.Join(projectRoot, checkpointFile))
+		results := updateChannels(targets, len(args) > 0, projectRoot, checkpoint)
+		ok := reportUpdates(os.Stdout, append(append(results, missing...), unloaded(errs)...))
+
+		if complete(results) {
+			if err := checkpoint.Remove(); err != nil {
+				log.Printf("Failed to remove checkpoint %s: %v", checkpoint.Path(), err)
+			}
+		} else {
+			fmt.Printf("Progress saved to %s, run again with --resume to continue\n", checkpoint.Path())
+		}
+
+		if !ok {
+			printQuotaSummary()
+			os.Exit(1)
 		}
 	},
 }
 
 func init() {
 	channelCmd.AddCommand(updateCmd)
+
+	updateCmd.Flags().IntVar(&updateConcurrency, "concurrency", 1, "number of channels to refresh at the same time")
+	updateCmd.Flags().BoolVar(&updateResume, "resume", false, "continue an interrupted update from its checkpoint")
+	updateCmd.Flags().BoolVar(&updateFull, "full", false, "list every upload and re-import every video, instead of only the ones added since the last update")
 }
 
-func updateChannelList(args []string) {
-	projectRoot := os.ExpandEnv(viper.GetString("projectRoot"))
-	dataDir := path.Join(projectRoot, "/data/channels")
-	channels := util.LoadChannels(dataDir)
+// loadCheckpoint returns the saved checkpoint when resuming, and a fresh one
+// otherwise
+func loadCheckpoint(filePath string) *util.Checkpoint {
+	if !updateResume {
+		return util.NewCheckpoint(filePath)
+	}
 
-	for _, channelSlug := range args {
-		channel, ok := channels.Find(channelSlug)
-		if !ok {
-			log.Printf("Couldn't find channel with slug '%s', skipping...", channelSlug)
-			continue
+	checkpoint, err := util.LoadCheckpoint(filePath)
+	if os.IsNotExist(err) {
+		log.Printf("No checkpoint found at %s, starting from the beginning", filePath)
+		return util.NewCheckpoint(filePath)
+	}
+	if err != nil {
+		log.Fatalf("Failed to load checkpoint %s: %v", filePath, err)
+	}
+
+	log.Printf("Resuming from %s, %d channels already done", filePath, len(checkpoint.Done))
+	return checkpoint
+}
+
+// unloaded turns the channel files that couldn't be loaded into failed updates,
+// named after the file
+func unloaded(errs []error) []channelUpdate {
+	var failed []channelUpdate
+	for _, err := range errs {
+		slug := ""
+		if channelErr, ok := err.(*util.ChannelError); ok {
+			slug = strings.TrimSuffix(path.Base(channelErr.File), ".yml")
+			err = channelErr.Err
 		}
+		failed = append(failed, channelUpdate{channel: &util.Channel{Slug: slug}, err: err})
+	}
+	return failed
+}
 
-		url := channel.YouTubeURL()
-		if url == nil {
-			log.Printf("Failed to update channel %s (%s), missing URL", channel.Name, channel.Slug)
-			continue
+// channelUpdate is the outcome of refreshing a single channel
+type channelUpdate struct {
+	channel *util.Channel
+	// fields are the fields of the channel that changed
+	fields []string
+	// skipped says why the channel wasn't finished, e.g. the quota budget
+	// ran out, it is empty when the channel was attempted
+	skipped string
+	// resumed is set when the channel was finished by an earlier run
+	resumed bool
+	err     error
+}
+
+// channelUpdater holds the state shared by the workers of an update
+type channelUpdater struct {
+	youtube providers.Provider
+	// quota tracks the calls made by youtube, it is nil when youtube doesn't
+	// track its quota
+	quota        *providers.QuotaTracker
+	importVideos bool
+	projectRoot  string
+	dataDir      string
+	checkpoint   *util.Checkpoint
+	interrupted  int32
+}
+
+// updateChannels refreshes the channels using a pool of updateConcurrency
+// workers, returning the results in the same order as channels. Channels the
+// checkpoint has as done are not refreshed again. Once the YouTube quota
+// budget is reached, or on Ctrl-C, the remaining channels are skipped.
+func updateChannels(channels []*util.Channel, importVideos bool, projectRoot string, checkpoint *util.Checkpoint) []channelUpdate {
+	youtube := youtubeProvider()
+	updater := &channelUpdater{
+		youtube:      youtube,
+		quota:        quotaTracker(youtube),
+		importVideos: importVideos,
+		projectRoot:  projectRoot,
+		dataDir:      path.Join(projectRoot, "/data/channels"),
+		checkpoint:   checkpoint,
+	}
+
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+	go func() {
+		if _, ok := <-interrupts; ok {
+			log.Println("Interrupted, finishing the channels in progress...")
+			atomic.StoreInt32(&updater.interrupted, 1)
+			// A second Ctrl-C exits straight away, the checkpoint is already saved
+			signal.Stop(interrupts)
 		}
+	}()
 
-		youtube, err := providers.FetchDetails(url)
-		if err != nil {
-			log.Fatalf("failed to update channel %s: %v", channel.Slug, err)
+	return updater.runAll(channels, updateConcurrency)
+}
+
+// runAll refreshes the channels using a pool of workers, returning the
+// results in the same order as channels
+func (u *channelUpdater) runAll(channels []*util.Channel, workers int) []channelUpdate {
+	if workers < 1 {
+		workers = 1
+	}
+
+	results := make([]channelUpdate, len(channels))
+	jobs := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = u.run(channels[i])
+			}
+		}()
+	}
+
+	for i := range channels {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// run updates a single channel unless it is done already or the update has
+// to stop, and records the channel in the checkpoint once it is done
+func (u *channelUpdater) run(channel *util.Channel) channelUpdate {
+	switch {
+	case u.checkpoint.IsDone(channel.Slug):
+		return channelUpdate{channel: channel, resumed: true}
+	case atomic.LoadInt32(&u.interrupted) == 1:
+		return channelUpdate{channel: channel, skipped: "interrupted"}
+	case u.quotaExhausted():
+		return channelUpdate{channel: channel, skipped: "YouTube quota budget reached"}
+	}
+
+	fields, err := u.update(channel)
+	if err != nil {
+		if u.quotaExhausted() {
+			return channelUpdate{channel: channel, fields: fields, skipped: "YouTube quota budget reached"}
 		}
+		return channelUpdate{channel: channel, fields: fields, err: err}
+	}
 
-		channel.Providers["youtube"] = youtube
+	if err := u.checkpoint.MarkDone(channel.Slug); err != nil {
+		log.Printf("Failed to save checkpoint %s: %v", u.checkpoint.Path(), err)
+	}
+	return channelUpdate{channel: channel, fields: fields}
+}
 
-		for _, videoId := range channel.Providers["youtube"].Videos {
-			err = providers.ImportVideo(videoId, channelSlug, projectRoot)
+// quotaExhausted reports whether the YouTube provider has reached its quota
+// budget
+func (u *channelUpdater) quotaExhausted() bool {
+	return u.quota != nil && u.quota.Exhausted()
+}
+
+// update refreshes every provider of a single channel and saves it,
+// importing its YouTube videos as well when importVideos is set. A channel
+// without a YouTube URL only has its other providers refreshed. The channel
+// is saved before its videos are imported so a partial import keeps the
+// refreshed details. It returns the fields of the channel that changed.
+func (u *channelUpdater) update(channel *util.Channel) ([]string, error) {
+	before, err := yaml.Marshal(channel)
+	if err != nil {
+		return nil, err
+	}
 
-			if err != nil {
-				log.Printf("Failed to import videos %s", videoId)
+	url := channel.YouTubeURL()
+	var details util.Provider
+	var opts providers.ListOptions
+	if url != nil {
+		saveProgress := func(progress util.ChannelProgress) {
+			if err := u.checkpoint.SetProgress(channel.Slug, progress); err != nil {
+				log.Printf("Failed to save checkpoint %s: %v", u.checkpoint.Path(), err)
 			}
 		}
 
-		err = util.SaveChannel(channel, dataDir)
+		opts = providers.ListOptions{From: u.checkpoint.Progress(channel.Slug), OnPage: saveProgress}
+		if !updateFull {
+			opts.Previous = channel.Providers["youtube"].Videos
+			// A creator without a videos folder yet simply has no known videos
+			opts.Known, _ = util.GetCreatorVideos(channel.Slug, u.projectRoot)
+		}
+
+		details, err = providers.FetchDetailsWith(u.youtube, url, opts)
 		if err != nil {
-			log.Printf("Failed to update channel %s (%s), error: %v", channel.Name, channel.Slug, err)
+			return nil, err
+		}
+		if channel.Providers == nil {
+			channel.Providers = make(map[string]util.Provider)
 		}
+		channel.Providers["youtube"] = details
 	}
-}
 
-func updateChannels() {
-	projectRoot := os.ExpandEnv(viper.GetString("projectRoot"))
-	dataDir := path.Join(projectRoot, "/data/channels")
-	channels := util.LoadChannels(dataDir)
+	refreshErr := refreshProviders(channel)
 
-	for _, channel := range channels {
-		url := channel.YouTubeURL()
-		if url == nil {
-			log.Printf("Failed to update channel %s (%s), missing URL", channel.Name, channel.Slug)
-			continue
+	after, err := yaml.Marshal(channel)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := util.ChangedFields(before, after)
+	if err != nil {
+		return nil, err
+	}
+
+	err = util.SaveChannel(channel, u.dataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.importVideos && url != nil {
+		videos := details.Videos
+		if !updateFull {
+			videos = providers.UnimportedVideos(details, opts)
 		}
 
-		youtube, err := providers.FetchDetails(url)
+		err = providers.ImportVideos(u.youtube, videos, channel.Slug, u.projectRoot)
 		if err != nil {
-			log.Fatalf("failed to update channel %s: %v", channel.Slug, err)
+			return fields, err
 		}
+	}
+
+	return fields, refreshErr
+}
 
-		channel.Providers["youtube"] = youtube
+// reportUpdates writes a summary of every channel to w once all updates are
+// done, returning false if any channel failed
+func reportUpdates(w io.Writer, results []channelUpdate) bool {
+	var updated, unchanged, resumed, skipped, failed []string
+	for _, result := range results {
+		switch {
+		case result.resumed:
+			resumed = append(resumed, result.channel.Slug)
+		case result.skipped != "":
+			skipped = append(skipped, fmt.Sprintf("%s: %s", result.channel.Slug, result.skipped))
+		case result.err != nil:
+			failed = append(failed, fmt.Sprintf("%s: %v", result.channel.Slug, result.err))
+		case len(result.fields) > 0:
+			updated = append(updated, fmt.Sprintf("%s: %s", result.channel.Slug, strings.Join(result.fields, ", ")))
+		default:
+			unchanged = append(unchanged, result.channel.Slug)
+		}
+	}
+
+	fmt.Fprintf(w, "\n%d updated, %d unchanged, %d skipped, %d failed\n", len(updated), len(unchanged), len(skipped), len(failed))
+	if len(resumed) > 0 {
+		fmt.Fprintf(w, "Already done before resuming: %d\n", len(resumed))
+	}
+	if len(updated) > 0 {
+		fmt.Fprintln(w, "Updated:")
+		for _, update := range updated {
+			fmt.Fprintf(w, "  %s\n", update)
+		}
+	}
+	if len(skipped) > 0 {
+		fmt.Fprintln(w, "Skipped:")
+		for _, skip := range skipped {
+			fmt.Fprintf(w, "  %s\n", skip)
+		}
+	}
+	if len(failed) > 0 {
+		fmt.Fprintln(w, "Failed:")
+		for _, failure := range failed {
+			fmt.Fprintf(w, "  %s\n", failure)
+		}
+	}
 
-		err = util.SaveChannel(&channel, dataDir)
+	return len(failed) == 0
+}
+
+// complete reports whether every channel was either updated or done by an
+// earlier run, so there is nothing left to resume
+func complete(results []channelUpdate) bool {
+	for _, result := range results {
+		if result.err != nil || result.skipped != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// refreshProviders updates every provider entry on the channel other than
+// YouTube, which is refreshed separately along with its videos
+func refreshProviders(channel *util.Channel) error {
+	var failures []string
+	for name, entry := range channel.Providers {
+		if name == "youtube" || entry.URL == nil {
+			continue
+		}
+
+		provider, ok := Providers.Get(name)
+		if !ok {
+			continue
+		}
+
+		details, err := providers.FetchDetails(providers.ForURL(provider, entry.URL), entry.URL)
 		if err != nil {
-			log.Printf("Failed to update channel %s (%s), error: %v", channel.Name, channel.Slug, err)
+			failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+			continue
 		}
+		channel.Providers[name] = details
+	}
+
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		return fmt.Errorf("failed to refresh %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// sortedSlugs returns the slugs of every channel in alphabetical order
+func sortedSlugs(channels util.ChannelList) []string {
+	slugs := make([]string, 0, len(channels))
+	for slug := range channels {
+		slugs = append(slugs, slug)
+	}
+	sort.Strings(slugs)
+	return slugs
+}
+
+// quotaTracker returns the tracker counting the quota used by provider, nil
+// when it doesn't track its quota
+func quotaTracker(provider providers.Provider) *providers.QuotaTracker {
+	if youtube, ok := provider.(*providers.YouTubeProvider); ok {
+		return youtube.Quota
+	}
+	return nil
+}
+
+func youtubeProvider() providers.Provider {
+	youtube, ok := Providers.Get("youtube")
+	if !ok {
+		log.Fatal("youtube provider is not registered")
 	}
+	return youtube
 }
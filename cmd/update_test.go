@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/breadtubetv/bake/providers"
+	"github.com/breadtubetv/bake/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubYouTube stands in for the YouTube provider. Calls to FetchChannel wait
+// until concurrent of them are in flight at once, so an update that doesn't
+// run its channels in parallel fails instead of passing by luck.
+type stubYouTube struct {
+	concurrent int
+	failing    string
+
+	mu       sync.Mutex
+	inFlight int
+	gate     chan struct{}
+}
+
+func (s *stubYouTube) Configure() error { return nil }
+
+func (s *stubYouTube) FetchChannel(channelURL *util.URL) (util.Provider, error) {
+	s.mu.Lock()
+	s.inFlight++
+	if s.inFlight == s.concurrent {
+		close(s.gate)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-s.gate:
+	case <-time.After(5 * time.Second):
+		return util.Provider{}, fmt.Errorf("fewer than %d channels were fetched at once", s.concurrent)
+	}
+
+	name := path.Base(channelURL.Path)
+	if name == s.failing {
+		return util.Provider{}, errors.New("channel not found")
+	}
+	return util.Provider{Name: name, URL: channelURL, Subscribers: 100}, nil
+}
+
+func (s *stubYouTube) FetchVideo(id string) (*providers.Video, error) {
+	return &providers.Video{ID: id, Source: "youtube"}, nil
+}
+
+func (s *stubYouTube) ListChannelVideos(channelURL *util.URL) ([]string, error) {
+	return nil, nil
+}
+
+func (s *stubYouTube) VideoID(videoURL *util.URL) (string, error) {
+	return path.Base(videoURL.Path), nil
+}
+
+func (s *stubYouTube) MatchesURL(u *util.URL) bool { return u.Host == "www.youtube.com" }
+
+func testChannel(slug string) *util.Channel {
+	return &util.Channel{Name: slug, Slug: slug, Providers: map[string]util.Provider{
+		"youtube": {URL: util.MustParseURL("https://www.youtube.com/user/" + slug)},
+	}}
+}
+
+func TestChannelUpdater(t *testing.T) {
+	projectRoot, err := ioutil.TempDir("", "bake")
+	require.NoError(t, err)
+	defer os.RemoveAll(projectRoot)
+	dataDir := path.Join(projectRoot, "data/channels")
+	require.NoError(t, os.MkdirAll(dataDir, 0755))
+
+	updater := &channelUpdater{
+		youtube:     &stubYouTube{concurrent: 3, failing: "angiespeaks", gate: make(chan struct{})},
+		projectRoot: projectRoot,
+		dataDir:     dataDir,
+		checkpoint:  util.NewCheckpoint(path.Join(projectRoot, checkpointFile)),
+	}
+	channels := []*util.Channel{testChannel("anarchopac"), testChannel("angiespeaks"), testChannel("contrapoints")}
+
+	results := updater.runAll(channels, 3)
+	require.Len(t, results, 3)
+	for i, result := range results {
+		assert.Equal(t, channels[i], result.channel)
+	}
+	assert.NoError(t, results[0].err)
+	assert.EqualError(t, results[1].err, "channel not found")
+	assert.NoError(t, results[2].err)
+	assert.False(t, complete(results))
+
+	assert.True(t, updater.checkpoint.IsDone("anarchopac"))
+	assert.False(t, updater.checkpoint.IsDone("angiespeaks"))
+	assert.True(t, updater.checkpoint.IsDone("contrapoints"))
+
+	saved, errs := util.LoadChannels(dataDir)
+	require.Empty(t, errs)
+	assert.True(t, saved.Contains("anarchopac"))
+	assert.False(t, saved.Contains("angiespeaks"))
+	assert.True(t, saved.Contains("contrapoints"))
+
+	out := bytes.Buffer{}
+	assert.False(t, reportUpdates(&out, results))
+	assert.Contains(t, out.String(), "\n2 updated, 0 unchanged, 0 skipped, 1 failed\n")
+	assert.Contains(t, out.String(), "Failed:\n  angiespeaks: channel not found\n")
+}
+
+func TestReportUpdates(t *testing.T) {
+	out := bytes.Buffer{}
+	ok := reportUpdates(&out, []channelUpdate{
+		{channel: &util.Channel{Slug: "anarchopac"}, fields: []string{"providers.youtube.subscribers"}},
+		{channel: &util.Channel{Slug: "angiespeaks"}, err: errors.New("channel not found")},
+		{channel: &util.Channel{Slug: "contrapoints"}},
+		{channel: &util.Channel{Slug: "hbomberguy"}, skipped: "YouTube quota budget reached"},
+		{channel: &util.Channel{Slug: "philosophytube"}, resumed: true},
+	})
+	assert.False(t, ok, "a failed channel should make the update exit with status 1")
+	assert.Equal(t, `
+1 updated, 1 unchanged, 1 skipped, 1 failed
+Already done before resuming: 1
+Updated:
+  anarchopac: providers.youtube.subscribers
+Skipped:
+  hbomberguy: YouTube quota budget reached
+Failed:
+  angiespeaks: channel not found
+`, out.String())
+
+	out.Reset()
+	ok = reportUpdates(&out, []channelUpdate{{channel: &util.Channel{Slug: "contrapoints"}}})
+	assert.True(t, ok, "an update without failures should exit with status 0")
+	assert.Equal(t, "\n0 updated, 1 unchanged, 0 skipped, 0 failed\n", out.String())
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/breadtubetv/bake/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check every channel and video file for problems",
+	Long: `Check every channel and video file, reporting every problem found with its file
+	and line: anything their schema doesn't allow, such as a missing name, a bad URL
+	or a value of the wrong type, and a slug that doesn't match its file name, a slug
+	used twice, a video in the wrong creator's folder, videos for an unknown creator,
+	a missing profile image or a .yaml file bake wouldn't read.
+
+	Exits with status 1 if there are any problems, so it can be used to check pull
+	requests.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		projectRoot := os.ExpandEnv(viper.GetString("projectRoot"))
+
+		problems, err := util.Validate(projectRoot, schemas)
+		if err != nil {
+			log.Fatalf("Failed to validate %s: %v", projectRoot, err)
+		}
+
+		for _, problem := range problems {
+			if rel, err := filepath.Rel(projectRoot, problem.File); err == nil {
+				problem.File = rel
+			}
+			fmt.Println(problem)
+		}
+
+		if len(problems) > 0 {
+			fmt.Printf("\n%d problems found\n", len(problems))
+			os.Exit(1)
+		}
+		fmt.Println("No problems found")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+}
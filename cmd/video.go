@@ -1,11 +1,13 @@
 package cmd
 
 import (
+	"fmt"
 	"log"
 	"os"
-	"regexp"
+	"strings"
 
 	"github.com/breadtubetv/bake/providers"
+	"github.com/breadtubetv/bake/util"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -13,8 +15,10 @@ import (
 // videoCmd represents the video command
 var videoCmd = &cobra.Command{
 	Use:   "video",
-	Short: "Import a video by ID",
-	Long:  `Import a YouTube video by ID and assign it to a creator.`,
+	Short: "Import a video by ID or URL",
+	Long: fmt.Sprintf(`Import a video from a provider by ID or URL and assign it to a creator.
+
+	Available providers: %s`, strings.Join(ProviderNames(), ", ")),
 	Run: func(cmd *cobra.Command, args []string) {
 		if id == "" && url == "" {
 			log.Fatal("command must include either video ID or URL")
@@ -23,17 +27,27 @@ var videoCmd = &cobra.Command{
 			log.Fatal("both video ID and URL provided, expected only one")
 		}
 
+		var p providers.Provider
 		if url != "" {
-			re := regexp.MustCompile(`(?:v|embed|watch\?v)(?:=|/)([^"&?/=%]{11})`)
-			if match := re.MatchString(url); match {
-				subs := re.FindStringSubmatch(url)
-				id = subs[1]
-			} else {
-				log.Fatal("the given URL is not a valid YouTube URL")
+			videoURL, err := util.ParseURL(url)
+			if err != nil {
+				log.Fatalf("Improperly formatted URL provided '%s': %v", url, err)
+			}
+
+			provider, p = providerForURL(provider, videoURL)
+			p = providers.ForURL(p, videoURL)
+			id, err = p.VideoID(videoURL)
+			if err != nil {
+				log.Fatalf("the given URL is not a valid %s URL: %v", provider, err)
+			}
+		} else {
+			if provider == "" {
+				log.Fatal("a video ID needs --provider as well")
 			}
+			p = getProvider(provider)
 		}
 
-		err := providers.ImportVideo(id, creator, os.ExpandEnv(viper.GetString("projectRoot")))
+		err := providers.ImportVideo(p, id, creator, os.ExpandEnv(viper.GetString("projectRoot")))
 		if err != nil {
 			log.Fatalf("could not import video: %v", err)
 		}
@@ -53,8 +67,7 @@ func init() {
 	videoCmd.Flags().StringVar(&id, "id", "", "ID of the video, e.g. xspEtjnSfQA is the ID for https://www.youtube.com/watch?v=xspEtjnSfQA")
 	videoCmd.Flags().StringVarP(&url, "url", "u", "", "URL of the video, e.g. https://www.youtube.com/watch?v=xspEtjnSfQA. Use instead of --id.")
 	videoCmd.Flags().StringVarP(&creator, "creator", "c", "", "Creator slug for the imported video")
-	videoCmd.Flags().StringVarP(&provider, "provider", "p", "", "Video provider to import from - e.g. youtube")
+	videoCmd.Flags().StringVarP(&provider, "provider", "p", "", fmt.Sprintf("Video provider to import from - one of %s. Told from the URL when left out.", strings.Join(ProviderNames(), ", ")))
 
 	videoCmd.MarkFlagRequired("creator")
-	videoCmd.MarkFlagRequired("provider")
 }
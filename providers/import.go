@@ -0,0 +1,217 @@
+package providers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path"
+
+	"github.com/breadtubetv/bake/util"
+	"gopkg.in/yaml.v2"
+)
+
+func formatChannelDetails(name string, provider Provider, slug string, channelURL *util.URL) (util.Channel, error) {
+	details, err := FetchDetails(provider, channelURL)
+	if err != nil {
+		return util.Channel{}, err
+	}
+
+	return util.Channel{
+		Name:      details.Name,
+		Slug:      slug,
+		Providers: map[string]util.Provider{name: details},
+	}, nil
+}
+
+// ImportChannel fetches the channel at channelURL from the provider registered
+// as name and saves it, along with its profile image and videos, under
+// projectRoot. An existing channel with the same slug keeps its name, permalink
+// and other providers.
+func ImportChannel(name string, provider Provider, slug string, channelURL *util.URL, projectRoot string) error {
+	dataDir := path.Join(projectRoot, "/data/channels")
+	// A channel file that can't be loaded might be the one being imported,
+	// which would be overwritten
+	channelList, errs := util.LoadChannels(dataDir)
+	if len(errs) > 0 {
+		return util.LoadErrors(errs)
+	}
+	provider = ForURL(provider, channelURL)
+
+	importedChannel, err := formatChannelDetails(name, provider, slug, channelURL)
+	if err != nil {
+		return fmt.Errorf("error obtaining channel info: %v", err)
+	}
+
+	channel, ok := channelList.Find(slug)
+	if ok {
+		log.Printf("Channel with slug '%s' already exists, updating.", slug)
+	} else {
+		channel.SchemaVersion = util.ChannelSchemaVersion
+	}
+	if channel.Providers == nil {
+		channel.Providers = make(map[string]util.Provider)
+	}
+	// An existing channel keeps its name and permalink, which may have been
+	// set by hand or come from another provider
+	if channel.Name == "" {
+		channel.Name = importedChannel.Name
+	}
+	if channel.Permalink == "" {
+		channel.Permalink = importedChannel.Slug
+	}
+	channel.Slug = importedChannel.Slug
+	channel.Providers[name] = importedChannel.Providers[name]
+
+	log.Printf("Title: %s, Count: %d\n", channel.Name, channel.Providers[name].Subscribers)
+	if images, ok := provider.(profileImageProvider); ok {
+		imgURL, err := images.FetchProfileImageURL(channelURL)
+		if err == nil {
+			err = saveImage(imgURL, slug, projectRoot)
+		}
+
+		if err != nil {
+			log.Println(err.Error())
+		}
+	}
+
+	err = util.SaveChannel(channel, dataDir)
+	if err != nil {
+		return fmt.Errorf("error saving channel '%s': %v", slug, err)
+	}
+
+	_ = util.CreateChannelVideoFolder(channel, projectRoot)
+
+	err = ImportVideos(provider, channel.Providers[name].Videos, channel.Slug, projectRoot)
+	if err != nil {
+		log.Println(err.Error())
+	}
+
+	return nil
+}
+
+func saveImage(imgURL string, slug string, projectRoot string) error {
+	resp, err := http.Get(imgURL)
+	if err != nil {
+		return fmt.Errorf("couldn't retreive image: %v", err)
+	}
+	defer resp.Body.Close()
+
+	filePath := util.ChannelImagePath(slug, projectRoot)
+	log.Printf("Saving %s", filePath)
+	err = util.WriteFileFrom(filePath, resp.Body)
+	if err != nil {
+		return fmt.Errorf("Error saving channel profile picture, please download manually.\nErr: %v", err.Error())
+	}
+	return nil
+}
+
+// ImportVideo will import a video from the provider based on an ID and create
+// a new file in the videos data folder for the specified creator
+func ImportVideo(provider Provider, id, creator, projectRoot string) error {
+	creatorDir, err := creatorVideoDir(creator, projectRoot)
+	if err != nil {
+		return err
+	}
+
+	vid, err := provider.FetchVideo(id)
+	if err != nil {
+		return err
+	}
+
+	return saveVideo(vid, creator, creatorDir)
+}
+
+// ImportVideos imports every video in ids for the creator, fetching them in
+// batches when the provider supports it. Videos that fail are logged and
+// skipped, and counted in the returned error.
+func ImportVideos(provider Provider, ids []string, creator, projectRoot string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	creatorDir, err := creatorVideoDir(creator, projectRoot)
+	if err != nil {
+		return err
+	}
+	failed := 0
+
+	var videos []*Video
+	if batch, ok := provider.(batchVideoProvider); ok {
+		var err error
+		videos, err = batch.FetchVideos(ids)
+		if err != nil {
+			log.Printf("Failed to fetch videos for %s: %v", creator, err)
+		}
+		failed += len(ids) - len(videos)
+	} else {
+		for _, id := range ids {
+			vid, err := provider.FetchVideo(id)
+			if err != nil {
+				log.Printf("Failed to import video %s: %v", id, err)
+				failed++
+				continue
+			}
+			videos = append(videos, vid)
+		}
+	}
+
+	for _, vid := range videos {
+		if err := saveVideo(vid, creator, creatorDir); err != nil {
+			log.Printf("Failed to import video %s: %v", vid.ID, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to import %d of %d videos for %s", failed, len(ids), creator)
+	}
+	return nil
+}
+
+// creatorVideoDir returns the creator's videos data folder, creating it if
+// needed
+func creatorVideoDir(creator, projectRoot string) (string, error) {
+	channels, errs := util.LoadChannels(projectRoot + "/data/channels")
+	channel, ok := channels.Find(creator)
+	if !ok && len(errs) > 0 {
+		return "", util.LoadErrors(errs)
+	}
+	if !ok {
+		return "", fmt.Errorf("creator %v not found", creator)
+	}
+
+	creatorDir := util.ChannelVideoFolder(creator, projectRoot)
+	if _, err := os.Stat(creatorDir); os.IsNotExist(err) {
+		err := util.CreateChannelVideoFolder(channel, projectRoot)
+		if err != nil {
+			return "", fmt.Errorf("unable to create folder for %v: %v", creator, err)
+		}
+	}
+
+	return creatorDir, nil
+}
+
+func saveVideo(vid *Video, creator, creatorDir string) error {
+	vid.SchemaVersion = util.VideoSchemaVersion
+	vid.Channel = creator
+
+	videoFile := fmt.Sprintf("%s/%s.yml", creatorDir, vid.ID)
+	data, err := yaml.Marshal(vid)
+	if err != nil {
+		return fmt.Errorf("couldn't marshal video data: %v", err)
+	}
+
+	_, statErr := os.Stat(videoFile)
+	written, err := util.WriteFileIfChanged(videoFile, data)
+	if err != nil {
+		return fmt.Errorf("couldn't write to file: %s: %v", videoFile, err)
+	}
+	if written && os.IsNotExist(statErr) {
+		log.Printf("created video file %v", videoFile)
+	} else if written {
+		log.Printf("updated video file %v", videoFile)
+	}
+
+	return nil
+}
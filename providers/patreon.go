@@ -0,0 +1,113 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/breadtubetv/bake/util"
+)
+
+const patreonBaseURL = "https://www.patreon.com"
+
+// PatreonProvider fetches campaign details from Patreon's public campaign API
+type PatreonProvider struct {
+	// BaseURL is where API requests are sent, it defaults to patreon.com and
+	// can be pointed at a local server in tests
+	BaseURL string
+	Client  *http.Client
+}
+
+// LoadPatreon initialises the Patreon provider and registers it as "patreon"
+func LoadPatreon(registry *Registry) *PatreonProvider {
+	provider := &PatreonProvider{BaseURL: patreonBaseURL, Client: http.DefaultClient}
+	registry.Register("patreon", provider)
+	return provider
+}
+
+// patreonCampaignResponse is the subset of the JSON:API campaign document bake
+// makes use of
+type patreonCampaignResponse struct {
+	Data []struct {
+		ID         string `json:"id"`
+		Attributes struct {
+			Name         string `json:"name"`
+			CreationName string `json:"creation_name"`
+			PatronCount  uint64 `json:"patron_count"`
+			URL          string `json:"url"`
+			Vanity       string `json:"vanity"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+// Configure is a no-op as the campaign API does not require credentials
+func (p *PatreonProvider) Configure() error {
+	log.Printf("Patreon does not require any configuration.")
+	return nil
+}
+
+// MatchesURL reports whether the URL points at patreon.com
+func (p *PatreonProvider) MatchesURL(u *util.URL) bool {
+	return strings.TrimPrefix(strings.ToLower(u.Host), "www.") == "patreon.com"
+}
+
+// FetchChannel returns the name, description and patron count of the
+// campaign at a https://www.patreon.com/<vanity> URL
+func (p *PatreonProvider) FetchChannel(channelURL *util.URL) (util.Provider, error) {
+	vanity := path.Base(channelURL.Path)
+	if vanity == "" || vanity == "/" || vanity == "." {
+		return util.Provider{}, fmt.Errorf("could not find campaign name in URL %s", channelURL)
+	}
+
+	query := url.Values{}
+	query.Set("filter[vanity]", vanity)
+	query.Set("fields[campaign]", "name,creation_name,patron_count,url,vanity")
+	endpoint := fmt.Sprintf("%s/api/campaigns?%s", strings.TrimSuffix(p.BaseURL, "/"), query.Encode())
+
+	resp, err := p.Client.Get(endpoint)
+	if err != nil {
+		return util.Provider{}, fmt.Errorf("error calling the Patreon API: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return util.Provider{}, fmt.Errorf("error calling the Patreon API: %s", resp.Status)
+	}
+
+	campaigns := patreonCampaignResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&campaigns); err != nil {
+		return util.Provider{}, fmt.Errorf("couldn't decode Patreon campaign: %v", err)
+	}
+
+	if len(campaigns.Data) == 0 {
+		return util.Provider{}, fmt.Errorf("could not find campaign '%s'", vanity)
+	}
+
+	campaign := campaigns.Data[0].Attributes
+	return util.Provider{
+		Name:        campaign.Name,
+		Slug:        vanity,
+		URL:         channelURL,
+		Description: campaign.CreationName,
+		Subscribers: campaign.PatronCount,
+	}, nil
+}
+
+// ListChannelVideos returns no videos, Patreon posts are not imported
+func (p *PatreonProvider) ListChannelVideos(channelURL *util.URL) ([]string, error) {
+	return nil, nil
+}
+
+// FetchVideo always fails as Patreon does not host videos bake can import
+func (p *PatreonProvider) FetchVideo(id string) (*Video, error) {
+	return nil, fmt.Errorf("patreon does not provide videos")
+}
+
+// VideoID always fails as Patreon does not host videos bake can import
+func (p *PatreonProvider) VideoID(videoURL *util.URL) (string, error) {
+	return "", fmt.Errorf("patreon does not provide videos")
+}
@@ -0,0 +1,69 @@
+package providers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/breadtubetv/bake/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const patreonCampaignJSON = `{
+	"data": [{
+		"id": "154329",
+		"type": "campaign",
+		"attributes": {
+			"name": "anarchopac",
+			"creation_name": "left-wing youtube videos",
+			"patron_count": 412,
+			"url": "https://www.patreon.com/anarchopac",
+			"vanity": "anarchopac"
+		}
+	}]
+}`
+
+func patreonServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/campaigns", r.URL.Path)
+		if r.URL.Query().Get("filter[vanity]") != "anarchopac" {
+			fmt.Fprint(w, `{"data": []}`)
+			return
+		}
+		fmt.Fprint(w, patreonCampaignJSON)
+	}))
+}
+
+func TestPatreonFetchChannel(t *testing.T) {
+	server := patreonServer(t)
+	defer server.Close()
+
+	patreon := &PatreonProvider{BaseURL: server.URL, Client: server.Client()}
+	channelURL := util.MustParseURL("https://www.patreon.com/anarchopac")
+
+	details, err := patreon.FetchChannel(channelURL)
+	require.NoError(t, err)
+	assert.Equal(t, "anarchopac", details.Name)
+	assert.Equal(t, "anarchopac", details.Slug)
+	assert.Equal(t, "left-wing youtube videos", details.Description)
+	assert.Equal(t, uint64(412), details.Subscribers)
+	assert.Equal(t, channelURL, details.URL)
+	assert.Empty(t, details.Videos)
+}
+
+func TestPatreonFetchChannel_NotFound(t *testing.T) {
+	server := patreonServer(t)
+	defer server.Close()
+
+	patreon := &PatreonProvider{BaseURL: server.URL, Client: server.Client()}
+	_, err := patreon.FetchChannel(util.MustParseURL("https://www.patreon.com/nobody"))
+	assert.Error(t, err)
+}
+
+func TestPatreonMatchesURL(t *testing.T) {
+	patreon := &PatreonProvider{}
+	assert.True(t, patreon.MatchesURL(util.MustParseURL("https://www.patreon.com/anarchopac")))
+	assert.False(t, patreon.MatchesURL(util.MustParseURL("https://www.youtube.com/user/anarchopac")))
+}
@@ -0,0 +1,192 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/breadtubetv/bake/util"
+)
+
+// peerTubePageSize is the number of videos requested per page, the maximum
+// most instances allow
+const peerTubePageSize = 100
+
+// PeerTubeProvider fetches channels and videos from the REST API of a
+// PeerTube instance
+type PeerTubeProvider struct {
+	Client *http.Client
+	// Instance is the base URL of the instance videos are looked up on, e.g.
+	// https://peertube.social. It is taken from the channel or video URL.
+	Instance string
+}
+
+// LoadPeerTube initialises the PeerTube provider and registers it as "peertube"
+func LoadPeerTube(registry *Registry) *PeerTubeProvider {
+	provider := &PeerTubeProvider{Client: http.DefaultClient}
+	registry.Register("peertube", provider)
+	return provider
+}
+
+type peerTubeChannel struct {
+	Name           string `json:"name"`
+	DisplayName    string `json:"displayName"`
+	Description    string `json:"description"`
+	FollowersCount uint64 `json:"followersCount"`
+}
+
+type peerTubeVideo struct {
+	UUID        string `json:"uuid"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	PublishedAt string `json:"publishedAt"`
+}
+
+type peerTubeVideoList struct {
+	Total int             `json:"total"`
+	Data  []peerTubeVideo `json:"data"`
+}
+
+// Configure is a no-op as the instance is read from each URL
+func (p *PeerTubeProvider) Configure() error {
+	log.Printf("PeerTube does not require any configuration, the instance is read from the URL.")
+	return nil
+}
+
+// ForInstance returns a copy of the provider that talks to the instance
+// hosting u
+func (p *PeerTubeProvider) ForInstance(u *util.URL) Provider {
+	return &PeerTubeProvider{
+		Client:   p.Client,
+		Instance: fmt.Sprintf("%s://%s", u.Scheme, u.Host),
+	}
+}
+
+// MatchesURL reports whether the URL looks like a PeerTube channel or watch
+// page. PeerTube is self-hosted so only the path can be checked.
+func (p *PeerTubeProvider) MatchesURL(u *util.URL) bool {
+	for _, prefix := range []string{"/c/", "/w/", "/video-channels/", "/videos/watch/"} {
+		if strings.HasPrefix(u.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// FetchChannel returns the details of a https://<instance>/c/<channel>
+// channel
+func (p *PeerTubeProvider) FetchChannel(channelURL *util.URL) (util.Provider, error) {
+	handle, err := peerTubeChannelHandle(channelURL)
+	if err != nil {
+		return util.Provider{}, err
+	}
+
+	channel := peerTubeChannel{}
+	endpoint := fmt.Sprintf("%s://%s/api/v1/video-channels/%s", channelURL.Scheme, channelURL.Host, url.PathEscape(handle))
+	if err := p.get(endpoint, &channel); err != nil {
+		return util.Provider{}, err
+	}
+
+	name := channel.DisplayName
+	if name == "" {
+		name = channel.Name
+	}
+
+	return util.Provider{
+		Name:        name,
+		Slug:        handle,
+		URL:         channelURL,
+		Description: channel.Description,
+		Subscribers: channel.FollowersCount,
+	}, nil
+}
+
+// ListChannelVideos returns the UUIDs of every video on the channel, newest
+// first
+func (p *PeerTubeProvider) ListChannelVideos(channelURL *util.URL) ([]string, error) {
+	handle, err := peerTubeChannelHandle(channelURL)
+	if err != nil {
+		return nil, err
+	}
+
+	channelVideos := make([]string, 0)
+	for start := 0; ; start += peerTubePageSize {
+		page := peerTubeVideoList{}
+		endpoint := fmt.Sprintf("%s://%s/api/v1/video-channels/%s/videos?sort=-publishedAt&start=%d&count=%d",
+			channelURL.Scheme, channelURL.Host, url.PathEscape(handle), start, peerTubePageSize)
+		if err := p.get(endpoint, &page); err != nil {
+			return nil, err
+		}
+
+		for _, video := range page.Data {
+			channelVideos = append(channelVideos, video.UUID)
+		}
+
+		if len(page.Data) == 0 || start+len(page.Data) >= page.Total {
+			break
+		}
+	}
+
+	return channelVideos, nil
+}
+
+// FetchVideo retrieves video details from the provider's instance
+func (p *PeerTubeProvider) FetchVideo(id string) (*Video, error) {
+	if p.Instance == "" {
+		return nil, fmt.Errorf("no PeerTube instance known for video '%s', import it by URL instead", id)
+	}
+
+	video := peerTubeVideo{}
+	endpoint := fmt.Sprintf("%s/api/v1/videos/%s", strings.TrimSuffix(p.Instance, "/"), url.PathEscape(id))
+	if err := p.get(endpoint, &video); err != nil {
+		return nil, err
+	}
+
+	return &Video{
+		ID:          video.UUID,
+		Title:       video.Name,
+		Description: video.Description,
+		PublishDate: video.PublishedAt,
+		Source:      "peertube",
+	}, nil
+}
+
+// VideoID extracts the video ID from /w/<id> and /videos/watch/<id> URLs
+func (p *PeerTubeProvider) VideoID(videoURL *util.URL) (string, error) {
+	for _, prefix := range []string{"/w/", "/videos/watch/"} {
+		if id := strings.TrimPrefix(videoURL.Path, prefix); id != videoURL.Path && id != "" {
+			return strings.TrimSuffix(id, "/"), nil
+		}
+	}
+	return "", fmt.Errorf("%s is not a PeerTube video URL", videoURL)
+}
+
+func (p *PeerTubeProvider) get(endpoint string, out interface{}) error {
+	resp, err := p.Client.Get(endpoint)
+	if err != nil {
+		return fmt.Errorf("error calling the PeerTube API: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error calling the PeerTube API: %s returned %s", endpoint, resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("couldn't decode PeerTube response from %s: %v", endpoint, err)
+	}
+	return nil
+}
+
+// peerTubeChannelHandle extracts the channel handle from /c/<handle> and
+// /video-channels/<handle> URLs, ignoring any trailing /videos
+func peerTubeChannelHandle(channelURL *util.URL) (string, error) {
+	parts := strings.Split(strings.Trim(channelURL.Path, "/"), "/")
+	if len(parts) < 2 || (parts[0] != "c" && parts[0] != "video-channels") || parts[1] == "" {
+		return "", fmt.Errorf("%s is not a PeerTube channel URL", channelURL)
+	}
+	return parts[1], nil
+}
@@ -0,0 +1,139 @@
+package providers
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/breadtubetv/bake/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func peerTubeServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/video-channels/bookclub", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"name": "bookclub", "displayName": "Book Club", "description": "Reading theory together", "followersCount": 57}`)
+	})
+	mux.HandleFunc("/api/v1/video-channels/bookclub/videos", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("start") {
+		case "0":
+			fmt.Fprint(w, `{"total": 2, "data": [{"uuid": "9c9de5e8-0a1e-484a-b099-e80766180a6d"}]}`)
+		default:
+			fmt.Fprint(w, `{"total": 2, "data": [{"uuid": "2f2b4d3c-8e5a-4b8f-9a6e-3c1d2b7e6f10"}]}`)
+		}
+	})
+	mux.HandleFunc("/api/v1/videos/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"uuid": "%s", "name": "Chapter One", "description": "We start reading", "publishedAt": "2019-05-01T12:00:00.000Z"}`, path.Base(r.URL.Path))
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestPeerTubeFetchDetails(t *testing.T) {
+	server := peerTubeServer(t)
+	defer server.Close()
+
+	peertube := &PeerTubeProvider{Client: server.Client()}
+	channelURL := util.MustParseURL(server.URL + "/c/bookclub/videos")
+
+	details, err := FetchDetails(peertube, channelURL)
+	require.NoError(t, err)
+	assert.Equal(t, "Book Club", details.Name)
+	assert.Equal(t, "bookclub", details.Slug)
+	assert.Equal(t, uint64(57), details.Subscribers)
+	assert.Equal(t, []string{"9c9de5e8-0a1e-484a-b099-e80766180a6d", "2f2b4d3c-8e5a-4b8f-9a6e-3c1d2b7e6f10"}, details.Videos)
+}
+
+func TestPeerTubeFetchVideo_NoInstance(t *testing.T) {
+	_, err := (&PeerTubeProvider{}).FetchVideo("9c9de5e8-0a1e-484a-b099-e80766180a6d")
+	assert.Error(t, err)
+}
+
+func TestPeerTubeImportChannel(t *testing.T) {
+	server := peerTubeServer(t)
+	defer server.Close()
+
+	projectRoot, err := ioutil.TempDir("", "bake")
+	require.NoError(t, err)
+	defer os.RemoveAll(projectRoot)
+	require.NoError(t, os.MkdirAll(path.Join(projectRoot, "data/channels"), 0755))
+	require.NoError(t, os.MkdirAll(path.Join(projectRoot, "data/videos"), 0755))
+
+	peertube := &PeerTubeProvider{Client: server.Client()}
+	err = ImportChannel("peertube", peertube, "bookclub", util.MustParseURL(server.URL+"/c/bookclub"), projectRoot)
+	require.NoError(t, err)
+
+	channels, errs := util.LoadChannels(path.Join(projectRoot, "data/channels"))
+	require.Empty(t, errs)
+	channel, ok := channels.Find("bookclub")
+	require.True(t, ok)
+	assert.Equal(t, "Book Club", channel.Providers["peertube"].Name)
+
+	data, err := ioutil.ReadFile(path.Join(projectRoot, "data/videos/bookclub/9c9de5e8-0a1e-484a-b099-e80766180a6d.yml"))
+	require.NoError(t, err)
+
+	video := Video{}
+	require.NoError(t, yaml.Unmarshal(data, &video))
+	assert.Equal(t, "peertube", video.Source)
+	assert.Equal(t, "bookclub", video.Channel)
+	assert.Equal(t, "Chapter One", video.Title)
+}
+
+func TestPeerTubeImportChannel_Existing(t *testing.T) {
+	server := peerTubeServer(t)
+	defer server.Close()
+
+	projectRoot, err := ioutil.TempDir("", "bake")
+	require.NoError(t, err)
+	defer os.RemoveAll(projectRoot)
+	dataDir := path.Join(projectRoot, "data/channels")
+	require.NoError(t, os.MkdirAll(dataDir, 0755))
+	require.NoError(t, os.MkdirAll(path.Join(projectRoot, "data/videos"), 0755))
+	require.NoError(t, ioutil.WriteFile(path.Join(dataDir, "bookclub.yml"), []byte(`name: The Book Club
+slug: bookclub
+permalink: the-book-club
+providers:
+  youtube:
+    url: https://www.youtube.com/channel/UCUtloyZ_Iu4BJekIqPLc_fQ
+`), 0644))
+
+	peertube := &PeerTubeProvider{Client: server.Client()}
+	err = ImportChannel("peertube", peertube, "bookclub", util.MustParseURL(server.URL+"/c/bookclub"), projectRoot)
+	require.NoError(t, err)
+
+	channels, errs := util.LoadChannels(dataDir)
+	require.Empty(t, errs)
+	channel, ok := channels.Find("bookclub")
+	require.True(t, ok)
+	assert.Equal(t, "The Book Club", channel.Name)
+	assert.Equal(t, "the-book-club", channel.Permalink)
+	assert.Equal(t, []string{"peertube", "youtube"}, channel.ProviderNames())
+	assert.Equal(t, "Book Club", channel.Providers["peertube"].Name)
+}
+
+func TestPeerTubeMatchesURL(t *testing.T) {
+	peertube := &PeerTubeProvider{}
+	assert.True(t, peertube.MatchesURL(util.MustParseURL("https://peertube.social/c/bookclub")))
+	assert.True(t, peertube.MatchesURL(util.MustParseURL("https://peertube.social/w/kkGMgK9ZtnKfYAgnEtQxbv")))
+	assert.False(t, peertube.MatchesURL(util.MustParseURL("https://www.patreon.com/anarchopac")))
+}
+
+func TestPeerTubeVideoID(t *testing.T) {
+	peertube := &PeerTubeProvider{}
+
+	id, err := peertube.VideoID(util.MustParseURL("https://peertube.social/w/kkGMgK9ZtnKfYAgnEtQxbv"))
+	assert.NoError(t, err)
+	assert.Equal(t, "kkGMgK9ZtnKfYAgnEtQxbv", id)
+
+	id, err = peertube.VideoID(util.MustParseURL("https://peertube.social/videos/watch/9c9de5e8-0a1e-484a-b099-e80766180a6d"))
+	assert.NoError(t, err)
+	assert.Equal(t, "9c9de5e8-0a1e-484a-b099-e80766180a6d", id)
+
+	_, err = peertube.VideoID(util.MustParseURL("https://peertube.social/c/bookclub"))
+	assert.Error(t, err)
+}
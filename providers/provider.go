@@ -0,0 +1,223 @@
+package providers
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/breadtubetv/bake/util"
+)
+
+// Provider is implemented by every content source bake can import channels
+// and videos from
+type Provider interface {
+	// Configure performs any interactive setup the provider needs, such as
+	// authenticating and caching credentials
+	Configure() error
+	// FetchChannel returns the details of the channel at channelURL, without
+	// its video list
+	FetchChannel(channelURL *util.URL) (util.Provider, error)
+	// FetchVideo returns the details of a single video
+	FetchVideo(id string) (*Video, error)
+	// ListChannelVideos returns the IDs of every video uploaded to the channel
+	ListChannelVideos(channelURL *util.URL) ([]string, error)
+	// VideoID extracts the ID FetchVideo expects from a video's URL
+	VideoID(videoURL *util.URL) (string, error)
+	// MatchesURL reports whether the URL belongs to this provider
+	MatchesURL(u *util.URL) bool
+}
+
+// profileImageProvider is implemented by providers that can supply a
+// channel's profile picture
+type profileImageProvider interface {
+	FetchProfileImageURL(channelURL *util.URL) (string, error)
+}
+
+// pagedVideoLister is implemented by providers that list a channel's videos,
+// newest first, a page at a time and can continue from a previously reached
+// page. Listing stops early when onPage returns false.
+type pagedVideoLister interface {
+	ListChannelVideosFrom(channelURL *util.URL, from util.ChannelProgress, onPage func(util.ChannelProgress) bool) ([]string, error)
+}
+
+// batchVideoProvider is implemented by providers that can fetch many videos
+// in a single request
+type batchVideoProvider interface {
+	FetchVideos(ids []string) ([]*Video, error)
+}
+
+// Video represents a video imported from a provider
+type Video struct {
+	SchemaVersion int    `yaml:"schema_version"`
+	ID            string `yaml:"id"`
+	Title         string
+	Description   string
+	Source        string
+	Channel       string
+	PublishDate   string
+}
+
+// ExtendSchema adds what the fields of Video don't say about video files to
+// their schema
+func (Video) ExtendSchema(s *util.Schema) {
+	s.Title = "BreadTube video"
+	s.Required = []string{"id", "channel"}
+	s.Properties["id"].MinLength = 1
+	s.Properties["channel"].MinLength = 1
+}
+
+// Registry holds the providers available to bake, keyed by name
+type Registry struct {
+	providers map[string]Provider
+	order     []string
+}
+
+// NewRegistry returns an empty provider registry
+func NewRegistry() *Registry {
+	return &Registry{providers: make(map[string]Provider)}
+}
+
+// Register adds a provider to the registry, replacing any provider already
+// registered under the same name
+func (r *Registry) Register(name string, provider Provider) {
+	if _, ok := r.providers[name]; !ok {
+		r.order = append(r.order, name)
+	}
+	r.providers[name] = provider
+}
+
+// Get returns the provider registered under name
+func (r *Registry) Get(name string) (Provider, bool) {
+	provider, ok := r.providers[name]
+	return provider, ok
+}
+
+// Names returns the names of all registered providers in alphabetical order
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Match returns the name of the first provider, in registration order, that
+// recognises the URL
+func (r *Registry) Match(u *util.URL) (string, Provider, bool) {
+	for _, name := range r.order {
+		if provider := r.providers[name]; provider.MatchesURL(u) {
+			return name, provider, true
+		}
+	}
+	return "", nil, false
+}
+
+// instanceProvider is implemented by federated providers whose API is served
+// from the same host as the URLs they are given
+type instanceProvider interface {
+	ForInstance(u *util.URL) Provider
+}
+
+// ForURL returns the provider bound to the host of u when the provider is
+// federated, such as PeerTube, and the provider unchanged otherwise
+func ForURL(provider Provider, u *util.URL) Provider {
+	if federated, ok := provider.(instanceProvider); ok {
+		return federated.ForInstance(u)
+	}
+	return provider
+}
+
+// FetchDetails returns the channel details from the provider along with the
+// IDs of every video on the channel
+func FetchDetails(provider Provider, channelURL *util.URL) (util.Provider, error) {
+	return FetchDetailsWith(provider, channelURL, ListOptions{})
+}
+
+// ListOptions controls how FetchDetailsWith lists a channel's videos
+type ListOptions struct {
+	// From continues an earlier, interrupted listing
+	From util.ChannelProgress
+	// OnPage is called with the progress made after every page, when the
+	// provider lists videos a page at a time, so it can be saved
+	OnPage func(util.ChannelProgress)
+	// Previous is the video list from the last update. Listing stops at the
+	// first page containing one of these videos, or one in Known, and the
+	// rest of Previous is kept after the newly listed videos. Being in
+	// Previous doesn't mean a video has been imported.
+	Previous []string
+	// Known holds other video IDs already imported, e.g. the creator's video
+	// files, that also mark where listing can stop
+	Known []string
+}
+
+// FetchDetailsWith is FetchDetails with control over how the video list is
+// fetched, see ListOptions
+func FetchDetailsWith(provider Provider, channelURL *util.URL, opts ListOptions) (util.Provider, error) {
+	details, err := provider.FetchChannel(channelURL)
+	if err != nil {
+		return util.Provider{}, err
+	}
+
+	known := make(map[string]bool)
+	for _, ids := range [][]string{opts.Previous, opts.Known} {
+		for _, id := range ids {
+			known[id] = true
+		}
+	}
+
+	var videos []string
+	if paged, ok := provider.(pagedVideoLister); ok {
+		checked := 0
+		videos, err = paged.ListChannelVideosFrom(channelURL, opts.From, func(progress util.ChannelProgress) bool {
+			if opts.OnPage != nil {
+				opts.OnPage(progress)
+			}
+			for _, id := range progress.Videos[checked:] {
+				if known[id] {
+					return false
+				}
+			}
+			checked = len(progress.Videos)
+			return true
+		})
+	} else {
+		videos, err = provider.ListChannelVideos(channelURL)
+	}
+	if err != nil {
+		return util.Provider{}, fmt.Errorf("could not list videos for %s: %v", channelURL, err)
+	}
+	details.Videos = mergeVideos(videos, opts.Previous)
+
+	return details, nil
+}
+
+// NewVideos returns the videos that aren't in known, keeping their order
+func NewVideos(videos []string, known ...[]string) []string {
+	seen := make(map[string]bool)
+	for _, ids := range known {
+		for _, id := range ids {
+			seen[id] = true
+		}
+	}
+
+	fresh := make([]string, 0)
+	for _, id := range videos {
+		if !seen[id] {
+			fresh = append(fresh, id)
+		}
+	}
+	return fresh
+}
+
+// UnimportedVideos returns the videos of a channel listed with opts that
+// aren't in opts.Known. Only the video files show what has been imported, the
+// Previous list also has videos an earlier update listed but didn't import.
+func UnimportedVideos(details util.Provider, opts ListOptions) []string {
+	return NewVideos(details.Videos, opts.Known)
+}
+
+// mergeVideos appends the previous videos that weren't listed again to the
+// newly listed ones
+func mergeVideos(listed, previous []string) []string {
+	return append(listed, NewVideos(previous, listed)...)
+}
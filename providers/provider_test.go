@@ -0,0 +1,127 @@
+package providers
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/breadtubetv/bake/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+type stubProvider struct {
+	host   string
+	videos []string
+}
+
+func (s *stubProvider) Configure() error { return nil }
+
+func (s *stubProvider) FetchChannel(channelURL *util.URL) (util.Provider, error) {
+	return util.Provider{Name: "stub", URL: channelURL}, nil
+}
+
+func (s *stubProvider) FetchVideo(id string) (*Video, error) {
+	return &Video{ID: id, Source: "stub"}, nil
+}
+
+func (s *stubProvider) ListChannelVideos(channelURL *util.URL) ([]string, error) {
+	return s.videos, nil
+}
+
+func (s *stubProvider) VideoID(videoURL *util.URL) (string, error) {
+	return path.Base(videoURL.Path), nil
+}
+
+func (s *stubProvider) MatchesURL(u *util.URL) bool { return u.Host == s.host }
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("vimeo", &stubProvider{host: "vimeo.com"})
+	LoadYoutube(registry)
+
+	assert.Equal(t, []string{"vimeo", "youtube"}, registry.Names())
+
+	_, ok := registry.Get("patreon")
+	assert.False(t, ok)
+
+	name, _, ok := registry.Match(util.MustParseURL("https://www.youtube.com/watch?v=xspEtjnSfQA"))
+	require.True(t, ok)
+	assert.Equal(t, "youtube", name)
+
+	name, _, ok = registry.Match(util.MustParseURL("https://vimeo.com/123"))
+	require.True(t, ok)
+	assert.Equal(t, "vimeo", name)
+
+	_, _, ok = registry.Match(util.MustParseURL("https://example.com/123"))
+	assert.False(t, ok)
+}
+
+func TestFetchDetails(t *testing.T) {
+	channelURL := util.MustParseURL("https://vimeo.com/channels/staffpicks")
+	details, err := FetchDetails(&stubProvider{videos: []string{"1", "2"}}, channelURL)
+	assert.NoError(t, err)
+	assert.Equal(t, "stub", details.Name)
+	assert.Equal(t, channelURL, details.URL)
+	assert.Equal(t, []string{"1", "2"}, details.Videos)
+}
+
+func TestImportVideos(t *testing.T) {
+	projectRoot, err := ioutil.TempDir("", "bake")
+	require.NoError(t, err)
+	defer os.RemoveAll(projectRoot)
+	require.NoError(t, os.MkdirAll(path.Join(projectRoot, "data/channels"), 0755))
+	require.NoError(t, os.MkdirAll(path.Join(projectRoot, "data/videos"), 0755))
+	require.NoError(t, util.SaveChannel(&util.Channel{Name: "Stub", Slug: "stub"}, path.Join(projectRoot, "data/channels")))
+
+	err = ImportVideos(&stubProvider{}, []string{"1", "2"}, "stub", projectRoot)
+	require.NoError(t, err)
+
+	videos, err := util.GetCreatorVideos("stub", projectRoot)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1", "2"}, videos)
+}
+
+func TestVideoSchemaMatchesSavedVideos(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bake")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	video := &Video{
+		ID:          "5sd9Wd6R_Ms",
+		Title:       "Election special",
+		Description: "Australian politics",
+		Source:      "youtube",
+		PublishDate: "2019-05-01T10:00:00Z",
+	}
+	require.NoError(t, saveVideo(video, "friendlyjordies", dir))
+
+	data, err := ioutil.ReadFile(path.Join(dir, "5sd9Wd6R_Ms.yml"))
+	require.NoError(t, err)
+	doc := map[interface{}]interface{}{}
+	require.NoError(t, yaml.Unmarshal(data, &doc))
+	assert.Empty(t, util.GenerateSchema(Video{}).Validate(doc))
+
+	delete(doc, "channel")
+	assert.Equal(t, []util.SchemaError{{Path: "channel", Message: "is missing"}}, util.GenerateSchema(Video{}).Validate(doc))
+}
+
+func TestSaveVideoSkipsUnchanged(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bake")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	videoFile := path.Join(dir, "5sd9Wd6R_Ms.yml")
+
+	video := &Video{ID: "5sd9Wd6R_Ms", Title: "Election special", Source: "youtube"}
+	require.NoError(t, saveVideo(video, "friendlyjordies", dir))
+
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	require.NoError(t, os.Chtimes(videoFile, past, past))
+	require.NoError(t, saveVideo(video, "friendlyjordies", dir))
+	info, err := os.Stat(videoFile)
+	require.NoError(t, err)
+	assert.Equal(t, past, info.ModTime(), "an unchanged video shouldn't be written")
+}
@@ -0,0 +1,145 @@
+package providers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"sync"
+	"text/tabwriter"
+)
+
+// youtubeQuotaCosts is the cost in quota units of each YouTube Data API call
+// bake makes, see https://developers.google.com/youtube/v3/determine_quota_cost
+var youtubeQuotaCosts = map[string]int{
+	"channels.list":      1,
+	"playlistItems.list": 1,
+	"videos.list":        1,
+	"search.list":        100,
+}
+
+// QuotaTracker tallies the quota units used by YouTube API calls and refuses
+// to make calls that would go over Budget
+type QuotaTracker struct {
+	// Budget is the most units that may be used, zero means unlimited
+	Budget int
+
+	mu        sync.Mutex
+	used      int
+	exhausted bool
+	calls     map[string]int
+}
+
+// NewQuotaTracker returns a tracker that allows budget units to be used, or
+// any number of units when budget is zero
+func NewQuotaTracker(budget int) *QuotaTracker {
+	return &QuotaTracker{Budget: budget, calls: make(map[string]int)}
+}
+
+// Wrap returns a transport that charges every request to the tracker before
+// sending it with transport
+func (q *QuotaTracker) Wrap(transport http.RoundTripper) http.RoundTripper {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &quotaTransport{tracker: q, transport: transport}
+}
+
+// Used returns the number of units used so far
+func (q *QuotaTracker) Used() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.used
+}
+
+// Exhausted reports whether a call has been refused because of the budget
+func (q *QuotaTracker) Exhausted() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.exhausted
+}
+
+// Summary describes the calls made and the units they used, one call type per
+// line
+func (q *QuotaTracker) Summary() string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	callTypes := make([]string, 0, len(q.calls))
+	for callType := range q.calls {
+		callTypes = append(callTypes, callType)
+	}
+	sort.Strings(callTypes)
+
+	out := bytes.Buffer{}
+	if q.Budget > 0 {
+		fmt.Fprintf(&out, "YouTube quota used: %d of %d units\n", q.used, q.Budget)
+	} else {
+		fmt.Fprintf(&out, "YouTube quota used: %d units\n", q.used)
+	}
+
+	w := tabwriter.NewWriter(&out, 0, 0, 2, ' ', 0)
+	for _, callType := range callTypes {
+		calls := q.calls[callType]
+		fmt.Fprintf(w, "  %s\t%d calls\t%d units\n", callType, calls, calls*quotaCost(callType))
+	}
+	w.Flush()
+
+	return out.String()
+}
+
+// charge records a call, failing if it would take usage past the budget
+func (q *QuotaTracker) charge(callType string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	cost := quotaCost(callType)
+	if q.Budget > 0 && q.used+cost > q.Budget {
+		q.exhausted = true
+		return fmt.Errorf("YouTube quota budget of %d units reached, %s needs %d more", q.Budget, callType, cost)
+	}
+
+	if q.calls == nil {
+		q.calls = make(map[string]int)
+	}
+	q.used += cost
+	q.calls[callType]++
+	return nil
+}
+
+func quotaCost(callType string) int {
+	if cost, ok := youtubeQuotaCosts[callType]; ok {
+		return cost
+	}
+	return 1
+}
+
+// quotaTransport charges each request to a QuotaTracker
+type quotaTransport struct {
+	tracker   *QuotaTracker
+	transport http.RoundTripper
+}
+
+// RoundTrip charges the request and sends it if it fits in the budget
+func (t *quotaTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.tracker.charge(youtubeCallType(req)); err != nil {
+		return nil, err
+	}
+	return t.transport.RoundTrip(req)
+}
+
+// youtubeCallType names a request after the API resource and method, e.g.
+// GET .../youtube/v3/videos is videos.list
+func youtubeCallType(req *http.Request) string {
+	method := "list"
+	switch req.Method {
+	case http.MethodPost:
+		method = "insert"
+	case http.MethodPut:
+		method = "update"
+	case http.MethodDelete:
+		method = "delete"
+	}
+	return fmt.Sprintf("%s.%s", path.Base(req.URL.Path), method)
+}
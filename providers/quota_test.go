@@ -0,0 +1,53 @@
+package providers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotaTracker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"items": []}`)
+	}))
+	defer server.Close()
+
+	quota := NewQuotaTracker(3)
+	client := &http.Client{Transport: quota.Wrap(nil)}
+
+	for _, resource := range []string{"channels", "videos", "videos"} {
+		resp, err := client.Get(server.URL + "/youtube/v3/" + resource)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	assert.Equal(t, 3, quota.Used())
+	assert.False(t, quota.Exhausted())
+
+	_, err := client.Get(server.URL + "/youtube/v3/playlistItems")
+	assert.Error(t, err)
+	assert.True(t, quota.Exhausted())
+	assert.Equal(t, 3, quota.Used())
+
+	summary := quota.Summary()
+	assert.Contains(t, summary, "3 of 3 units")
+	assert.Regexp(t, `videos.list +2 calls +2 units`, summary)
+	assert.NotContains(t, summary, "playlistItems")
+}
+
+func TestYouTubeQuota(t *testing.T) {
+	youtube := replayYouTube()
+	youtube.Quota = NewQuotaTracker(0)
+
+	_, err := FetchDetails(youtube, friendlyJordiesURLParsed())
+	require.NoError(t, err)
+	_, err = youtube.FetchProfileImageURL(friendlyJordiesURLParsed())
+	require.NoError(t, err)
+
+	// channels.list once for the details, uploads playlist and profile image,
+	// then two pages of playlistItems.list
+	assert.Equal(t, 3, youtube.Quota.Used())
+}
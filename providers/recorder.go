@@ -0,0 +1,142 @@
+package providers
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// RecordingTransport saves every response to a fixture file in Dir, or with
+// Replay set serves the saved fixtures without touching the network. Fixtures
+// are keyed on the request method, path and query, excluding the API key, so
+// they work against any host.
+type RecordingTransport struct {
+	Dir    string
+	Replay bool
+	// Transport makes the real requests while recording, http.DefaultTransport
+	// is used when nil
+	Transport http.RoundTripper
+}
+
+// fixture is the on-disk format of a recorded response
+type fixture struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body"`
+}
+
+// RoundTrip records or replays a single request
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := fixtureKey(req)
+	file := filepath.Join(t.Dir, fixtureName(req, key))
+
+	if t.Replay {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("no fixture for %s, record it with --record: %v", key, err)
+		}
+
+		saved := fixture{}
+		if err := json.Unmarshal(data, &saved); err != nil {
+			return nil, fmt.Errorf("couldn't parse fixture %s: %v", file, err)
+		}
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", saved.Status, http.StatusText(saved.Status)),
+			StatusCode:    saved.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        saved.Header,
+			Body:          ioutil.NopCloser(strings.NewReader(saved.Body)),
+			ContentLength: int64(len(saved.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	transport := t.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read response for %s: %v", key, err)
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	// Escaping HTML would turn every & in the URL into \u0026
+	data := bytes.Buffer{}
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(fixture{
+		Method: req.Method,
+		URL:    strings.TrimPrefix(key, req.Method+" "),
+		Status: resp.StatusCode,
+		Header: resp.Header,
+		Body:   string(body),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't marshal fixture for %s: %v", key, err)
+	}
+
+	if err := os.MkdirAll(t.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("couldn't create fixture directory %s: %v", t.Dir, err)
+	}
+	if err := ioutil.WriteFile(file, data.Bytes(), 0644); err != nil {
+		return nil, fmt.Errorf("couldn't save fixture %s: %v", file, err)
+	}
+	log.Printf("Recorded %s", file)
+
+	return resp, nil
+}
+
+// fixtureKey identifies a request independently of the host it was sent to
+// and the credentials it was sent with
+func fixtureKey(req *http.Request) string {
+	query := req.URL.Query()
+	query.Del("key")
+
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	params := make([]string, 0, len(keys))
+	for _, k := range keys {
+		values := query[k]
+		sort.Strings(values)
+		for _, v := range values {
+			params = append(params, url.QueryEscape(k)+"="+url.QueryEscape(v))
+		}
+	}
+
+	return fmt.Sprintf("%s %s?%s", req.Method, req.URL.Path, strings.Join(params, "&"))
+}
+
+// fixtureName names the fixture file after the API resource, followed by a
+// hash of the full key
+func fixtureName(req *http.Request, key string) string {
+	sum := sha1.Sum([]byte(key))
+	return fmt.Sprintf("%s-%x.json", path.Base(req.URL.Path), sum[:5])
+}
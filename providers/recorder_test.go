@@ -0,0 +1,51 @@
+package providers
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordingTransport(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprintf(w, "hello %s", r.URL.Query().Get("name"))
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "fixtures")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	get := func(client *http.Client, url string) (string, error) {
+		resp, err := client.Get(url)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		return string(body), err
+	}
+
+	recorder := &http.Client{Transport: &RecordingTransport{Dir: dir}}
+	body, err := get(recorder, server.URL+"/greet?name=bread&key=secret")
+	require.NoError(t, err)
+	assert.Equal(t, "hello bread", body)
+
+	// The API key and host are not part of the fixture key
+	replayer := &http.Client{Transport: &RecordingTransport{Dir: dir, Replay: true}}
+	body, err = get(replayer, "https://example.com/greet?key=other&name=bread")
+	require.NoError(t, err)
+	assert.Equal(t, "hello bread", body)
+	assert.Equal(t, 1, requests)
+
+	_, err = get(replayer, "https://example.com/greet?name=roses")
+	assert.Error(t, err)
+}
@@ -0,0 +1,104 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/breadtubetv/bake/util"
+)
+
+const vimeoBaseURL = "https://vimeo.com"
+
+// vimeoVideoID matches the numeric ID at the end of vimeo.com/<id> and
+// vimeo.com/channels/<channel>/<id> URLs
+var vimeoVideoID = regexp.MustCompile(`/(\d+)/?$`)
+
+// VimeoProvider imports individual videos using Vimeo's oEmbed endpoint, which
+// needs no credentials. Channels are not supported as that requires the
+// authenticated API.
+type VimeoProvider struct {
+	// BaseURL is where oEmbed requests are sent, overridable for tests
+	BaseURL string
+	Client  *http.Client
+}
+
+// LoadVimeo initialises the Vimeo provider and registers it as "vimeo"
+func LoadVimeo(registry *Registry) *VimeoProvider {
+	provider := &VimeoProvider{BaseURL: vimeoBaseURL, Client: http.DefaultClient}
+	registry.Register("vimeo", provider)
+	return provider
+}
+
+type vimeoOEmbed struct {
+	VideoID     int    `json:"video_id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	UploadDate  string `json:"upload_date"`
+}
+
+// Configure is a no-op as oEmbed does not require credentials
+func (p *VimeoProvider) Configure() error {
+	log.Printf("Vimeo does not require any configuration.")
+	return nil
+}
+
+// MatchesURL reports whether the URL points at vimeo.com
+func (p *VimeoProvider) MatchesURL(u *util.URL) bool {
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	return host == "vimeo.com" || host == "player.vimeo.com"
+}
+
+// FetchChannel is not supported without the authenticated Vimeo API
+func (p *VimeoProvider) FetchChannel(channelURL *util.URL) (util.Provider, error) {
+	return util.Provider{}, fmt.Errorf("importing Vimeo channels is not supported, import videos individually")
+}
+
+// ListChannelVideos is not supported without the authenticated Vimeo API
+func (p *VimeoProvider) ListChannelVideos(channelURL *util.URL) ([]string, error) {
+	return nil, fmt.Errorf("importing Vimeo channels is not supported, import videos individually")
+}
+
+// VideoID extracts the numeric video ID from a Vimeo URL
+func (p *VimeoProvider) VideoID(videoURL *util.URL) (string, error) {
+	subs := vimeoVideoID.FindStringSubmatch(videoURL.Path)
+	if subs == nil {
+		return "", fmt.Errorf("%s is not a valid Vimeo URL", videoURL)
+	}
+	return subs[1], nil
+}
+
+// FetchVideo retrieves video details from Vimeo
+func (p *VimeoProvider) FetchVideo(id string) (*Video, error) {
+	query := url.Values{}
+	query.Set("url", fmt.Sprintf("%s/%s", vimeoBaseURL, id))
+	endpoint := fmt.Sprintf("%s/api/oembed.json?%s", strings.TrimSuffix(p.BaseURL, "/"), query.Encode())
+
+	resp, err := p.Client.Get(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("error calling the Vimeo API: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error calling the Vimeo API for video '%s': %s", id, resp.Status)
+	}
+
+	video := vimeoOEmbed{}
+	if err := json.NewDecoder(resp.Body).Decode(&video); err != nil {
+		return nil, fmt.Errorf("couldn't decode Vimeo video: %v", err)
+	}
+
+	return &Video{
+		ID:          strconv.Itoa(video.VideoID),
+		Title:       video.Title,
+		Description: video.Description,
+		PublishDate: video.UploadDate,
+		Source:      "vimeo",
+	}, nil
+}
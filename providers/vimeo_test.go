@@ -0,0 +1,43 @@
+package providers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/breadtubetv/bake/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVimeoFetchVideo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/oembed.json", r.URL.Path)
+		assert.Equal(t, "https://vimeo.com/76979871", r.URL.Query().Get("url"))
+		fmt.Fprint(w, `{"video_id": 76979871, "title": "The New Vimeo Player", "description": "It may look different", "upload_date": "2013-10-15 14:08:29"}`)
+	}))
+	defer server.Close()
+
+	vimeo := &VimeoProvider{BaseURL: server.URL, Client: server.Client()}
+	video, err := vimeo.FetchVideo("76979871")
+	require.NoError(t, err)
+	assert.Equal(t, "76979871", video.ID)
+	assert.Equal(t, "The New Vimeo Player", video.Title)
+	assert.Equal(t, "vimeo", video.Source)
+}
+
+func TestVimeoVideoID(t *testing.T) {
+	vimeo := &VimeoProvider{}
+
+	id, err := vimeo.VideoID(util.MustParseURL("https://vimeo.com/76979871"))
+	assert.NoError(t, err)
+	assert.Equal(t, "76979871", id)
+
+	id, err = vimeo.VideoID(util.MustParseURL("https://vimeo.com/channels/staffpicks/76979871"))
+	assert.NoError(t, err)
+	assert.Equal(t, "76979871", id)
+
+	_, err = vimeo.VideoID(util.MustParseURL("https://vimeo.com/staffpicks"))
+	assert.Error(t, err)
+}
@@ -3,7 +3,6 @@ package providers
 import (
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
 	"net"
 	"net/http"
@@ -13,14 +12,15 @@ import (
 	"os/user"
 	"path"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
+	"sync"
 
 	"github.com/breadtubetv/bake/util"
 	"golang.org/x/net/context"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
-	"gopkg.in/yaml.v2"
 
 	"google.golang.org/api/googleapi/transport"
 	"google.golang.org/api/youtube/v3"
@@ -38,267 +38,289 @@ const accessJSON = `{
   }
 }`
 
-// LoadYoutube initalises the Youtube service
-func LoadYoutube() map[string]interface{} {
-	return map[string]interface{}{
-		"config":         config,
-		"channel_import": importChannel,
-		"video_import":   ImportVideo,
-	}
+// youtubeMaxBatch is the most IDs videos.list accepts in a single call
+const youtubeMaxBatch = 50
+
+// YouTubeProvider fetches channels and videos from the YouTube Data API
+type YouTubeProvider struct {
+	// Client is used for every API call, when nil an authenticated client is
+	// created by getClient
+	Client *http.Client
+	// BasePath overrides the root of the API, e.g. to point at a local server
+	BasePath string
+	// Quota tallies the units used by every call, when set
+	Quota *QuotaTracker
+
+	mu      sync.Mutex
+	service *youtube.Service
+	// channels holds the channels.list result for each channel URL, so the
+	// details, uploads playlist and profile image of a channel cost one call
+	channels map[string]*youtube.Channel
 }
 
-func config() {
-	client := getClient(youtube.YoutubeReadonlyScope)
-
-	_, err := youtube.New(client)
-	if err != nil {
-		log.Fatalf("Error creating YouTube client: %v", err)
-	}
-
-	log.Printf("Successfully authenticated and cached credentials.")
+// LoadYoutube initalises the Youtube provider and registers it as "youtube"
+func LoadYoutube(registry *Registry) *YouTubeProvider {
+	provider := &YouTubeProvider{Quota: NewQuotaTracker(0)}
+	registry.Register("youtube", provider)
+	return provider
 }
 
-// FetchDetails returns the YouTube details for a channel
-func FetchDetails(channelURL *util.URL) (util.Provider, error) {
-	channelSlug := path.Base(channelURL.Path)
-	category := path.Base(path.Dir(channelURL.Path))
+// Record saves every API response the provider receives as a fixture in dir
+func (p *YouTubeProvider) Record(dir string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 
-	client := getClient(youtube.YoutubeReadonlyScope)
-	service, err := youtube.New(client)
+	client, err := p.client()
 	if err != nil {
-		return util.Provider{}, fmt.Errorf("error creating YouTube client: %v", err)
-	}
-
-	call := service.Channels.List("snippet,statistics,contentDetails")
-	if category == "channel" {
-		call = call.Id(channelSlug)
-	} else {
-		call = call.ForUsername(channelSlug)
-	}
-	response, err := call.Do()
-	handleError(err, "")
-
-	if len(response.Items) == 0 {
-		return util.Provider{}, fmt.Errorf("could not find channel from URL")
+		return err
 	}
 
-	channelName := ""
-	channelDescription := ""
-	channelSubscriberCount := uint64(0)
-	channelVideos := make([]string, 0)
-	for _, channel := range response.Items {
-		channelName = channel.Snippet.Title
-		channelDescription = channel.Snippet.Description
-		channelSubscriberCount = channel.Statistics.SubscriberCount
-
-		playlistId := channel.ContentDetails.RelatedPlaylists.Uploads
-		nextPageToken := ""
-		for {
-			// Retrieve next set of items in the playlist.
-			playlistResponse := playlistItemsList(service, "snippet", playlistId, nextPageToken)
-
-			for _, playlistItem := range playlistResponse.Items {
-				channelVideos = append(channelVideos, playlistItem.Snippet.ResourceId.VideoId)
-			}
-
-			// Set the token to retrieve the next page of results
-			// or exit the loop if all results have been retrieved.
-			nextPageToken = playlistResponse.NextPageToken
-			if nextPageToken == "" {
-				break
-			}
-		}
-		break
-	}
+	p.Client = &http.Client{Transport: &RecordingTransport{Dir: dir, Transport: client.Transport}}
+	p.service = nil
+	return nil
+}
 
-	return util.Provider{
-		Description: channelDescription,
-		Name:        channelName,
-		URL:         channelURL,
-		Slug:        channelSlug,
-		Subscribers: channelSubscriberCount,
-		Videos:      channelVideos,
-	}, nil
+// Replay serves API responses from the fixtures in dir instead of calling
+// YouTube, so no network access or credentials are needed
+func (p *YouTubeProvider) Replay(dir string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.Client = &http.Client{Transport: &RecordingTransport{Dir: dir, Replay: true}}
+	p.service = nil
 }
 
-// https://developers.google.com/youtube/v3/docs/playlistItems/list
-func playlistItemsList(service *youtube.Service, part string, playlistId string, pageToken string) *youtube.PlaylistItemListResponse {
-	call := service.PlaylistItems.List(part)
-	call = call.PlaylistId(playlistId)
-	if pageToken != "" {
-		call = call.PageToken(pageToken)
+func (p *YouTubeProvider) client() (*http.Client, error) {
+	if p.Client != nil {
+		return p.Client, nil
 	}
-	response, err := call.Do()
-	handleError(err, "")
-	return response
+	return getClient(youtube.YoutubeReadonlyScope)
 }
 
-func fetchProfileImageURL(url *util.URL) (string, error) {
-	id := path.Base(url.Path)
-	category := path.Base(path.Dir(url.Path))
-	client := getClient(youtube.YoutubeReadonlyScope)
+// Service returns the YouTube service shared by every call the provider
+// makes, authenticating on first use
+func (p *YouTubeProvider) Service() (*youtube.Service, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.service != nil {
+		return p.service, nil
+	}
 
-	youtubeSvc, err := youtube.New(client)
+	client, err := p.client()
 	if err != nil {
-		return "", fmt.Errorf("fetchProfileImage: Error while creating YouTube service: %v", err)
+		return nil, err
 	}
 
-	call := youtubeSvc.Channels.List("snippet").Fields("items(snippet/thumbnails)")
-	if category == "channel" {
-		call = call.Id(id)
-	} else {
-		call = call.ForUsername(id)
+	if p.Quota != nil {
+		tracked := *client
+		tracked.Transport = p.Quota.Wrap(client.Transport)
+		client = &tracked
 	}
-	response, err := call.Do()
+
+	service, err := youtube.New(client)
 	if err != nil {
-		return "", fmt.Errorf("Error retrieving channel profile picture, please download manually.\nErr: %v", err.Error())
+		return nil, fmt.Errorf("error creating YouTube client: %v", err)
+	}
+	if p.BasePath != "" {
+		service.BasePath = p.BasePath
 	}
 
-	imgURL := response.Items[0].Snippet.Thumbnails.Default.Url
-	return imgURL, nil
+	p.service = service
+	return service, nil
 }
 
-func saveImage(imgURL string, slug string, projectRoot string) error {
-	resp, err := http.Get(imgURL)
+// Configure authenticates with YouTube and caches the credentials
+func (p *YouTubeProvider) Configure() error {
+	client, err := getClient(youtube.YoutubeReadonlyScope)
 	if err != nil {
-		return fmt.Errorf("couldn't retreive image: %v", err)
+		return err
 	}
-	defer resp.Body.Close()
 
-	filePath := fmt.Sprintf("%s/static/img/channels/%s.jpg", projectRoot, slug)
-	img, _ := os.Create(filePath)
-	defer img.Close()
-
-	_, err = io.Copy(img, resp.Body)
+	_, err = youtube.New(client)
 	if err != nil {
-		return fmt.Errorf("Error saving channel profile picture, please download manually.\nErr: %v", err.Error())
+		return fmt.Errorf("error creating YouTube client: %v", err)
 	}
-	log.Printf("Saving %s", filePath)
+
+	log.Printf("Successfully authenticated and cached credentials.")
 	return nil
 }
 
-func formatChannelDetails(slug string, channelURL *util.URL) (util.Channel, error) {
-	provider, err := FetchDetails(channelURL)
-	if err != nil {
-		return util.Channel{}, err
-	}
-
-	return util.Channel{
-		Name:      provider.Name,
-		Slug:      slug,
-		Providers: map[string]util.Provider{"youtube": provider},
-	}, nil
+// MatchesURL reports whether the URL points at youtube.com or youtu.be
+func (p *YouTubeProvider) MatchesURL(u *util.URL) bool {
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	return host == "youtube.com" || host == "m.youtube.com" || host == "youtu.be"
 }
 
-func importChannel(slug string, channelURL *util.URL, projectRoot string) {
-	dataDir := path.Join(projectRoot, "/data/channels")
-	channelList := util.LoadChannels(dataDir)
+// FetchChannel returns the YouTube details for a channel
+func (p *YouTubeProvider) FetchChannel(channelURL *util.URL) (util.Provider, error) {
+	channelSlug := path.Base(channelURL.Path)
 
-	importedChannel, err := formatChannelDetails(slug, channelURL)
+	channel, err := p.fetchChannel(channelURL)
 	if err != nil {
-		log.Fatalf("Error obtaining channel info: %v", err)
+		return util.Provider{}, err
 	}
 
-	channel, ok := channelList.Find(slug)
-	if ok {
-		log.Printf("Channel with slug '%s' already exists, updating.", slug)
-	}
-	channel.Name = importedChannel.Name
-	channel.Slug = importedChannel.Slug
-	channel.Permalink = importedChannel.Slug
-	channel.Providers = importedChannel.Providers
+	return util.Provider{
+		Description: channel.Snippet.Description,
+		Name:        channel.Snippet.Title,
+		URL:         channelURL,
+		Slug:        channelSlug,
+		Subscribers: channel.Statistics.SubscriberCount,
+	}, nil
+}
 
-	log.Printf("Title: %s, Count: %d\n", channel.Name, channel.Providers["youtube"].Subscribers)
-	imgURL, err := fetchProfileImageURL(channelURL)
-	if err == nil {
-		// TrimSuffix will need to be switched to projectRoot when we merge #185
-		err = saveImage(imgURL, slug, strings.TrimSuffix(dataDir, "/data/channels"))
-	}
+// ListChannelVideos returns the IDs of every video in the channel's uploads
+// playlist
+func (p *YouTubeProvider) ListChannelVideos(channelURL *util.URL) ([]string, error) {
+	return p.ListChannelVideosFrom(channelURL, util.ChannelProgress{}, nil)
+}
 
+// ListChannelVideosFrom lists the uploads playlist, newest first, starting at
+// the page token in from and keeping the videos already listed before it.
+// onPage, when set, is called with the progress made after every page and
+// listing stops early if it returns false.
+func (p *YouTubeProvider) ListChannelVideosFrom(channelURL *util.URL, from util.ChannelProgress, onPage func(util.ChannelProgress) bool) ([]string, error) {
+	service, err := p.Service()
 	if err != nil {
-		log.Println(err.Error())
+		return nil, err
 	}
 
-	err = util.SaveChannel(channel, dataDir)
+	channel, err := p.cachedChannel(channelURL)
 	if err != nil {
-		log.Fatalf("Error saving channel '%s': %v", slug, err)
+		return nil, err
 	}
 
-	_ = util.CreateChannelVideoFolder(channel, projectRoot)
-
-	for _, videoId := range channel.Providers["youtube"].Videos {
-		err = ImportVideo(videoId, channel.Slug, projectRoot)
+	channelVideos := make([]string, 0)
+	playlistId := channel.ContentDetails.RelatedPlaylists.Uploads
+	nextPageToken := from.PageToken
+	if nextPageToken != "" {
+		channelVideos = append(channelVideos, from.Videos...)
+	}
+	for {
+		// Retrieve next set of items in the playlist.
+		playlistResponse, err := playlistItemsList(service, "snippet", playlistId, nextPageToken)
+		if err != nil {
+			return nil, fmt.Errorf("error listing uploads playlist %s: %v", playlistId, err)
+		}
 
+		for _, playlistItem := range playlistResponse.Items {
+			channelVideos = append(channelVideos, playlistItem.Snippet.ResourceId.VideoId)
+		}
 
-		if err != nil {
-			log.Printf("Failed to import videos %s", videoId)
+		// Set the token to retrieve the next page of results
+		// or exit the loop if all results have been retrieved.
+		nextPageToken = playlistResponse.NextPageToken
+		if onPage != nil && !onPage(util.ChannelProgress{PageToken: nextPageToken, Videos: channelVideos}) {
+			break
+		}
+		if nextPageToken == "" {
+			break
 		}
 	}
+
+	return channelVideos, nil
 }
 
-// ImportVideo will import a YouTube video based on an ID and create
-// a new file in the videos data folder for the specified creator
-func ImportVideo(id, creator, projectRoot string) error {
-	channel, ok := util.LoadChannels(projectRoot + "/data/channels").Find(creator)
-	if !ok {
-		log.Fatalf("creator %v not found", creator)
-	}
+// channelParts are the parts of a channel every channels.list call asks for,
+// enough for FetchChannel, ListChannelVideosFrom and FetchProfileImageURL
+const channelParts = "snippet,statistics,contentDetails"
 
-	creatorDir := fmt.Sprintf("%s/data/videos/%s", projectRoot, creator)
-	if _, err := os.Stat(creatorDir); os.IsNotExist(err) {
-		err := util.CreateChannelVideoFolder(channel, projectRoot)
-		if err != nil {
-			log.Fatalf("unable to create folder for %v: %v", creator, err)
-		}
+// fetchChannel calls channels.list for the channel, keeping the result for
+// cachedChannel
+func (p *YouTubeProvider) fetchChannel(channelURL *util.URL) (*youtube.Channel, error) {
+	service, err := p.Service()
+	if err != nil {
+		return nil, err
 	}
 
-	vid, err := getVideo(id)
-	vid.Channel = creator
+	response, err := channelsList(service, channelParts, channelURL).Do()
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("error calling the YouTube API: %v", err)
 	}
 
-	videoFile := fmt.Sprintf("%s/%s.yml", creatorDir, vid.ID)
-	f, err := os.Create(videoFile)
-	if err != nil {
-		return fmt.Errorf("could not create file for video '%s': %v", id, err)
+	if len(response.Items) == 0 {
+		return nil, fmt.Errorf("could not find channel from URL")
 	}
-	defer f.Close()
 
-	data, err := yaml.Marshal(vid)
-	if err != nil {
-		return fmt.Errorf("couldn't marshal video data: %v", err)
+	channel := response.Items[0]
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.channels == nil {
+		p.channels = make(map[string]*youtube.Channel)
 	}
-	_, err = f.Write(data)
-	if err != nil {
-		return fmt.Errorf("couldn't write to file: %s: %v", f.Name(), err)
+	p.channels[channelURL.String()] = channel
+	return channel, nil
+}
+
+// cachedChannel returns the channel fetched last for the URL, only calling
+// channels.list if it hasn't been fetched yet
+func (p *YouTubeProvider) cachedChannel(channelURL *util.URL) (*youtube.Channel, error) {
+	p.mu.Lock()
+	channel, ok := p.channels[channelURL.String()]
+	p.mu.Unlock()
+	if ok {
+		return channel, nil
+	}
+	return p.fetchChannel(channelURL)
+}
+
+// channelsList builds a channels.list call for either a /channel/<id> or a
+// /user/<name> URL
+func channelsList(service *youtube.Service, part string, channelURL *util.URL) *youtube.ChannelsListCall {
+	id := path.Base(channelURL.Path)
+	category := path.Base(path.Dir(channelURL.Path))
+
+	call := service.Channels.List(part)
+	if category == "channel" {
+		return call.Id(id)
 	}
+	return call.ForUsername(id)
+}
 
-	err = f.Sync()
+// https://developers.google.com/youtube/v3/docs/playlistItems/list
+func playlistItemsList(service *youtube.Service, part string, playlistId string, pageToken string) (*youtube.PlaylistItemListResponse, error) {
+	call := service.PlaylistItems.List(part)
+	call = call.PlaylistId(playlistId)
+	if pageToken != "" {
+		call = call.PageToken(pageToken)
+	}
+	return call.Do()
+}
+
+// FetchProfileImageURL returns the URL of the channel's default thumbnail
+func (p *YouTubeProvider) FetchProfileImageURL(url *util.URL) (string, error) {
+	channel, err := p.cachedChannel(url)
 	if err != nil {
-		return fmt.Errorf("couldn't sync file: %s: %v", f.Name(), err)
+		return "", fmt.Errorf("Error retrieving channel profile picture, please download manually.\nErr: %v", err.Error())
 	}
-	log.Printf("created video file %v", videoFile)
 
-	return nil
+	imgURL := channel.Snippet.Thumbnails.Default.Url
+	return imgURL, nil
 }
 
-// Video represents the a YouTube video
-type Video struct {
-	ID          string `yaml:"id"`
-	Title       string
-	Description string
-	Source      string
-	Channel     string
-	PublishDate string
+// youtubeVideoID matches the ID in watch, embed and /v/ URLs
+var youtubeVideoID = regexp.MustCompile(`(?:v|embed|watch\?v)(?:=|/)([^"&?/=%]{11})`)
+
+// VideoID extracts the video ID from youtube.com watch, embed and youtu.be URLs
+func (p *YouTubeProvider) VideoID(videoURL *util.URL) (string, error) {
+	if strings.TrimPrefix(strings.ToLower(videoURL.Host), "www.") == "youtu.be" {
+		if id := strings.Trim(videoURL.Path, "/"); id != "" {
+			return id, nil
+		}
+	}
+
+	subs := youtubeVideoID.FindStringSubmatch(videoURL.String())
+	if subs == nil {
+		return "", fmt.Errorf("%s is not a valid YouTube URL", videoURL)
+	}
+	return subs[1], nil
 }
 
-// GetVideo retreives video details from YouTube
-func getVideo(videoID string) (*Video, error) {
-	client := getClient(youtube.YoutubeReadonlyScope)
-	yt, err := youtube.New(client)
+// FetchVideo retreives video details from YouTube
+func (p *YouTubeProvider) FetchVideo(videoID string) (*Video, error) {
+	yt, err := p.Service()
 	if err != nil {
-		return nil, fmt.Errorf("error creating YouTube client: %v", err)
+		return nil, err
 	}
 
 	call := yt.Videos.List("snippet").Id(videoID)
@@ -307,35 +329,61 @@ func getVideo(videoID string) (*Video, error) {
 		return nil, fmt.Errorf("error calling the YouTube API: %v", err)
 	}
 
-	video := &Video{
-		ID:          resp.Items[0].Id,
-		Title:       resp.Items[0].Snippet.Title,
-		Description: resp.Items[0].Snippet.Description,
-		PublishDate: resp.Items[0].Snippet.PublishedAt,
-		Source:      "youtube",
+	if len(resp.Items) == 0 {
+		return nil, fmt.Errorf("could not find video '%s'", videoID)
 	}
 
-	return video, nil
+	return youtubeVideo(resp.Items[0]), nil
 }
 
-const launchWebServer = true
+// FetchVideos retrieves the details of many videos, asking for up to
+// youtubeMaxBatch IDs per call. Videos YouTube doesn't return, e.g. because
+// they were deleted, are left out of the result.
+func (p *YouTubeProvider) FetchVideos(videoIDs []string) ([]*Video, error) {
+	yt, err := p.Service()
+	if err != nil {
+		return nil, err
+	}
+
+	videos := make([]*Video, 0, len(videoIDs))
+	for start := 0; start < len(videoIDs); start += youtubeMaxBatch {
+		end := start + youtubeMaxBatch
+		if end > len(videoIDs) {
+			end = len(videoIDs)
+		}
+
+		resp, err := yt.Videos.List("snippet").Id(strings.Join(videoIDs[start:end], ",")).Do()
+		if err != nil {
+			return videos, fmt.Errorf("error calling the YouTube API: %v", err)
+		}
 
-func handleError(err error, message string) {
-	if message == "" {
-		message = "Error making API call"
+		for _, item := range resp.Items {
+			videos = append(videos, youtubeVideo(item))
+		}
 	}
-	if err != nil {
-		log.Fatalf(message+": %v", err.Error())
+
+	return videos, nil
+}
+
+func youtubeVideo(item *youtube.Video) *Video {
+	return &Video{
+		ID:          item.Id,
+		Title:       item.Snippet.Title,
+		Description: item.Snippet.Description,
+		PublishDate: item.Snippet.PublishedAt,
+		Source:      "youtube",
 	}
 }
 
+const launchWebServer = true
+
 // getClient uses a Context and Config to retrieve a Token
 // then generate a Client. It returns the generated Client.
-func getClient(scope string) *http.Client {
+func getClient(scope string) (*http.Client, error) {
 	if apiKey := os.Getenv("YOUTUBE_API"); apiKey != "" {
 		return &http.Client{
 			Transport: &transport.APIKey{Key: apiKey},
-		}
+		}, nil
 	}
 
 	ctx := context.Background()
@@ -346,7 +394,7 @@ func getClient(scope string) *http.Client {
 	// at ~/.credentials/youtube-go.json
 	config, err := google.ConfigFromJSON(b, scope)
 	if err != nil {
-		log.Fatalf("Unable to parse client secret file to config: %v", err)
+		return nil, fmt.Errorf("unable to parse client secret file to config: %v", err)
 	}
 
 	// Use a redirect URI like this for a web app. The redirect URI must be a
@@ -357,7 +405,7 @@ func getClient(scope string) *http.Client {
 
 	cacheFile, err := tokenCacheFile()
 	if err != nil {
-		log.Fatalf("Unable to get path to cached credential file. %v", err)
+		return nil, fmt.Errorf("unable to get path to cached credential file: %v", err)
 	}
 	tok, err := tokenFromFile(cacheFile)
 	if err != nil {
@@ -369,11 +417,12 @@ func getClient(scope string) *http.Client {
 			fmt.Println("Trying to get token from prompt")
 			tok, err = getTokenFromPrompt(config, authURL)
 		}
-		if err == nil {
-			saveToken(cacheFile, tok)
+		if err != nil {
+			return nil, fmt.Errorf("unable to authenticate with YouTube: %v", err)
 		}
+		saveToken(cacheFile, tok)
 	}
-	return config.Client(ctx, tok)
+	return config.Client(ctx, tok), nil
 }
 
 // startWebServer starts a web server that listens on http://localhost:8080.
@@ -425,7 +474,7 @@ func openURL(url string) error {
 func exchangeToken(config *oauth2.Config, code string) (*oauth2.Token, error) {
 	tok, err := config.Exchange(context.TODO(), code)
 	if err != nil {
-		log.Fatalf("Unable to retrieve token %v", err)
+		return nil, fmt.Errorf("unable to retrieve token: %v", err)
 	}
 	return tok, nil
 }
@@ -439,7 +488,7 @@ func getTokenFromPrompt(config *oauth2.Config, authURL string) (*oauth2.Token, e
 		"line: \n%v\n", authURL)
 
 	if _, err := fmt.Scan(&code); err != nil {
-		log.Fatalf("Unable to read authorization code %v", err)
+		return nil, fmt.Errorf("unable to read authorization code: %v", err)
 	}
 	fmt.Println(authURL)
 	return exchangeToken(config, code)
@@ -456,7 +505,7 @@ func getTokenFromWeb(config *oauth2.Config, authURL string) (*oauth2.Token, erro
 
 	err = openURL(authURL)
 	if err != nil {
-		log.Fatalf("Unable to open authorization URL in web server: %v", err)
+		return nil, fmt.Errorf("unable to open authorization URL in web browser: %v", err)
 	} else {
 		fmt.Println("Your browser has been opened to an authorization URL.",
 			" This program will resume once authorization has been provided.")
@@ -505,7 +554,8 @@ func saveToken(file string, token *oauth2.Token) {
 	fmt.Printf("Saving credential file to: %s\n", file)
 	f, err := os.OpenFile(file, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
-		log.Fatalf("Unable to cache oauth token: %v", err)
+		log.Printf("Unable to cache oauth token: %v", err)
+		return
 	}
 	defer f.Close()
 	err = json.NewEncoder(f).Encode(token)
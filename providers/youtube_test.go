@@ -1,15 +1,170 @@
 package providers
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/breadtubetv/bake/util"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+const friendlyJordiesURL = "https://www.youtube.com/channel/UC2-i3KuYoODXsM99Z3-Gm0A"
+
+func friendlyJordiesURLParsed() *util.URL {
+	return util.MustParseURL(friendlyJordiesURL)
+}
+
+func replayYouTube() *YouTubeProvider {
+	youtube := &YouTubeProvider{}
+	youtube.Replay("testdata/youtube")
+	return youtube
+}
+
 func TestFormatChannelDetails(t *testing.T) {
-	channel, err := formatChannelDetails("Friendly-Jordies", util.MustParseURL("https://www.youtube.com/channel/UC2-i3KuYoODXsM99Z3-Gm0A"))
+	channel, err := formatChannelDetails("youtube", replayYouTube(), "Friendly-Jordies", util.MustParseURL(friendlyJordiesURL))
 	assert.NoError(t, err)
 	assert.Equal(t, "friendlyjordies", channel.Name)
 	assert.Equal(t, "Friendly-Jordies", channel.Slug)
 }
+
+func TestYouTubeFetchDetails(t *testing.T) {
+	details, err := FetchDetails(replayYouTube(), util.MustParseURL(friendlyJordiesURL))
+	require.NoError(t, err)
+	assert.Equal(t, "friendlyjordies", details.Name)
+	assert.Equal(t, "UC2-i3KuYoODXsM99Z3-Gm0A", details.Slug)
+	assert.Equal(t, uint64(412000), details.Subscribers)
+	assert.Equal(t, []string{"5sd9Wd6R_Ms", "Z1bGk2nQ3Lw", "qR7tY8uI9oP"}, details.Videos)
+}
+
+func TestYouTubeFetchVideo(t *testing.T) {
+	video, err := replayYouTube().FetchVideo("5sd9Wd6R_Ms")
+	require.NoError(t, err)
+	assert.Equal(t, "5sd9Wd6R_Ms", video.ID)
+	assert.Equal(t, "Election special", video.Title)
+	assert.Equal(t, "2019-05-01T09:00:00.000Z", video.PublishDate)
+	assert.Equal(t, "youtube", video.Source)
+
+	_, err = replayYouTube().FetchVideo("notrecorded")
+	assert.Error(t, err)
+}
+
+func TestYouTubeFetchProfileImageURL(t *testing.T) {
+	imgURL, err := replayYouTube().FetchProfileImageURL(util.MustParseURL(friendlyJordiesURL))
+	require.NoError(t, err)
+	assert.Equal(t, "https://yt3.ggpht.com/a/friendlyjordies=s88-c-k-c0xffffffff-no-rj-mo", imgURL)
+}
+
+func TestYouTubeVideoID(t *testing.T) {
+	youtube := &YouTubeProvider{}
+	for _, videoURL := range []string{
+		"https://www.youtube.com/watch?v=xspEtjnSfQA",
+		"https://www.youtube.com/embed/xspEtjnSfQA",
+		"https://youtu.be/xspEtjnSfQA",
+	} {
+		id, err := youtube.VideoID(util.MustParseURL(videoURL))
+		assert.NoError(t, err, videoURL)
+		assert.Equal(t, "xspEtjnSfQA", id, videoURL)
+	}
+
+	_, err := youtube.VideoID(util.MustParseURL("https://www.youtube.com/user/anarchopac"))
+	assert.Error(t, err)
+}
+
+func TestYouTubeBasePath(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/youtube/v3/videos", r.URL.Path)
+		fmt.Fprintf(w, `{"items": [{"id": "%s", "snippet": {"title": "From the fake server"}}]}`, r.URL.Query().Get("id"))
+	}))
+	defer server.Close()
+
+	youtube := &YouTubeProvider{Client: server.Client(), BasePath: server.URL + "/youtube/v3/"}
+	video, err := youtube.FetchVideo("5sd9Wd6R_Ms")
+	require.NoError(t, err)
+	assert.Equal(t, "From the fake server", video.Title)
+
+	first, err := youtube.Service()
+	require.NoError(t, err)
+	second, err := youtube.Service()
+	require.NoError(t, err)
+	assert.True(t, first == second, "service should be created once and reused")
+}
+
+func TestYouTubeFetchVideos(t *testing.T) {
+	batches := []int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ids := strings.Split(r.URL.Query().Get("id"), ",")
+		batches = append(batches, len(ids))
+
+		items := []string{}
+		for _, id := range ids {
+			if id == "deleted" {
+				continue
+			}
+			items = append(items, fmt.Sprintf(`{"id": "%s", "snippet": {"title": "Video %s"}}`, id, id))
+		}
+		fmt.Fprintf(w, `{"items": [%s]}`, strings.Join(items, ","))
+	}))
+	defer server.Close()
+
+	ids := []string{"deleted"}
+	for i := 1; i < 120; i++ {
+		ids = append(ids, fmt.Sprintf("video%d", i))
+	}
+
+	youtube := &YouTubeProvider{Client: server.Client(), BasePath: server.URL + "/youtube/v3/"}
+	videos, err := youtube.FetchVideos(ids)
+	require.NoError(t, err)
+	assert.Equal(t, []int{50, 50, 20}, batches)
+	require.Len(t, videos, 119)
+	assert.Equal(t, "Video video1", videos[0].Title)
+	assert.Equal(t, "youtube", videos[0].Source)
+}
+
+func TestYouTubeFetchDetailsFrom(t *testing.T) {
+	var pages []util.ChannelProgress
+	from := util.ChannelProgress{PageToken: "CAIQAA", Videos: []string{"5sd9Wd6R_Ms", "Z1bGk2nQ3Lw"}}
+
+	details, err := FetchDetailsWith(replayYouTube(), friendlyJordiesURLParsed(), ListOptions{
+		From: from,
+		OnPage: func(progress util.ChannelProgress) {
+			pages = append(pages, progress)
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"5sd9Wd6R_Ms", "Z1bGk2nQ3Lw", "qR7tY8uI9oP"}, details.Videos)
+
+	// Only the second page is fetched, and it is the last
+	require.Len(t, pages, 1)
+	assert.Equal(t, "", pages[0].PageToken)
+}
+
+func TestYouTubeFetchDetailsIncremental(t *testing.T) {
+	youtube := replayYouTube()
+	youtube.Quota = NewQuotaTracker(0)
+
+	previous := []string{"Z1bGk2nQ3Lw", "qR7tY8uI9oP", "oLdDeLeTeD0"}
+	details, err := FetchDetailsWith(youtube, friendlyJordiesURLParsed(), ListOptions{Previous: previous})
+	require.NoError(t, err)
+
+	// The first page already contains a known video, so the second isn't fetched
+	assert.Equal(t, 2, youtube.Quota.Used())
+	assert.Equal(t, []string{"5sd9Wd6R_Ms", "Z1bGk2nQ3Lw", "qR7tY8uI9oP", "oLdDeLeTeD0"}, details.Videos)
+	assert.Equal(t, []string{"5sd9Wd6R_Ms"}, NewVideos(details.Videos, previous))
+}
+
+func TestYouTubeFetchDetailsListedButNotImported(t *testing.T) {
+	// qR7tY8uI9oP was listed by an earlier update, but its video file was
+	// never written
+	previous := []string{"Z1bGk2nQ3Lw", "qR7tY8uI9oP"}
+	known := []string{"Z1bGk2nQ3Lw"}
+	opts := ListOptions{Previous: previous, Known: known}
+	details, err := FetchDetailsWith(replayYouTube(), friendlyJordiesURLParsed(), opts)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"5sd9Wd6R_Ms", "Z1bGk2nQ3Lw", "qR7tY8uI9oP"}, details.Videos)
+	assert.Equal(t, []string{"5sd9Wd6R_Ms", "qR7tY8uI9oP"}, UnimportedVideos(details, opts))
+}
@@ -0,0 +1,55 @@
+package util
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ChangedFields compares two versions of a YAML document, returning the path
+// of every field that was added, removed or changed, such as
+// providers.youtube.subscribers. Lists are compared as a whole.
+func ChangedFields(before, after []byte) ([]string, error) {
+	var a, b interface{}
+	if err := yaml.Unmarshal(before, &a); err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(after, &b); err != nil {
+		return nil, err
+	}
+
+	var fields []string
+	changedFields("", a, b, &fields)
+	return fields, nil
+}
+
+func changedFields(at string, a, b interface{}, fields *[]string) {
+	aMap, aOK := a.(map[interface{}]interface{})
+	bMap, bOK := b.(map[interface{}]interface{})
+	if !aOK || !bOK {
+		if !reflect.DeepEqual(a, b) {
+			*fields = append(*fields, at)
+		}
+		return
+	}
+
+	keys := make(map[string]interface{})
+	for key := range aMap {
+		keys[fmt.Sprint(key)] = key
+	}
+	for key := range bMap {
+		keys[fmt.Sprint(key)] = key
+	}
+	names := make([]string, 0, len(keys))
+	for name := range keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		key := keys[name]
+		changedFields(joinPath(at, name), aMap[key], bMap[key], fields)
+	}
+}
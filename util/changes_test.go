@@ -0,0 +1,44 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangedFields(t *testing.T) {
+	before := `name: anarchopac
+slug: anarchopac
+providers:
+  youtube:
+    subscribers: 100
+    videos: [a, b]
+  patreon:
+    subscribers: 10
+tags: [breadtube]
+`
+	after := `name: anarchopac
+slug: anarchopac
+providers:
+  youtube:
+    subscribers: 120
+    videos: [a, b, c]
+    description: Anarchism
+  patreon:
+    subscribers: 10
+`
+
+	fields, err := ChangedFields([]byte(before), []byte(after))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"providers.youtube.description",
+		"providers.youtube.subscribers",
+		"providers.youtube.videos",
+		"tags",
+	}, fields)
+
+	fields, err = ChangedFields([]byte(before), []byte(before))
+	require.NoError(t, err)
+	assert.Empty(t, fields)
+}
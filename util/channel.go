@@ -4,8 +4,9 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
-	"os"
 	"path"
+	"regexp"
+	"sort"
 	"strings"
 
 	yaml "gopkg.in/yaml.v2"
@@ -16,9 +17,41 @@ type Channel struct {
 	Name      string
 	Slug      string
 	Permalink string
+	// Aliases are permalinks the channel used to have, which the site
+	// redirects to the current one
+	Aliases   []string
 	Providers map[string]Provider
-	Tags      []interface{}
-	remnant   map[string]interface{}
+	Tags      []string
+	// SchemaVersion is the version of the format the channel file is in, zero
+	// when the file doesn't say
+	SchemaVersion int `yaml:"schema_version"`
+	remnant       map[string]interface{}
+	// file is where LoadChannels found the channel, which may not be named
+	// after its slug
+	file string
+}
+
+// ExtendSchema adds what the fields of Channel don't say about channel files
+// to their schema
+func (Channel) ExtendSchema(s *Schema) {
+	s.Title = "BreadTube channel"
+	s.Required = []string{"name", "slug"}
+	s.Properties["name"].MinLength = 1
+	s.Properties["slug"].MinLength = 1
+
+	// A single tag or alias can be given on its own rather than in a list,
+	// and tags like 2020 are read as numbers but kept all the same
+	s.Properties["tags"].Type = SchemaTypes{"array", "string"}
+	s.Properties["tags"].Items.Type = SchemaTypes{"string", "number", "boolean"}
+	s.Properties["aliases"].Type = SchemaTypes{"array", "string"}
+
+	// Fields bake doesn't know about are kept as they are, including the
+	// top level fields of version 1 files
+	s.AdditionalProperties = nil
+	deprecated := "Deprecated, moved into providers.youtube by bake migrate"
+	s.Properties["url"] = &Schema{Type: SchemaTypes{"string"}, Format: "uri", Description: deprecated}
+	s.Properties["description"] = &Schema{Type: SchemaTypes{"string"}, Description: deprecated}
+	s.Properties["subscribers"] = &Schema{Type: SchemaTypes{"integer"}, Description: deprecated}
 }
 
 // YouTubeURL fetches the URL if this channel has the encoded provider URL and
@@ -37,6 +70,90 @@ func (c Channel) YouTubeURL() *URL {
 	return nil
 }
 
+// HasTag reports whether the channel has the tag, ignoring case
+func (c Channel) HasTag(tag string) bool {
+	tag = NormalizeTag(tag)
+	for _, name := range c.Tags {
+		if NormalizeTag(name) == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// AddTag tags the channel, returning false if it had the tag already
+func (c *Channel) AddTag(tag string) bool {
+	tag = NormalizeTag(tag)
+	if tag == "" || c.HasTag(tag) {
+		return false
+	}
+	c.Tags = append(c.Tags, tag)
+	return true
+}
+
+// RemoveTag untags the channel, returning false if it didn't have the tag
+func (c *Channel) RemoveTag(tag string) bool {
+	tag = NormalizeTag(tag)
+	tags := make([]string, 0, len(c.Tags))
+	for _, name := range c.Tags {
+		if NormalizeTag(name) != tag {
+			tags = append(tags, name)
+		}
+	}
+
+	removed := len(tags) != len(c.Tags)
+	c.Tags = tags
+	return removed
+}
+
+// NormalizeTag lowercases a tag and trims any surrounding whitespace
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// NormalizeTags normalizes every tag, dropping empty and duplicate tags
+func NormalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// ProviderNames returns the names of the channel's providers in alphabetical
+// order
+func (c Channel) ProviderNames() []string {
+	names := make([]string, 0, len(c.Providers))
+	for name := range c.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Extra returns the fields in the channel file that bake doesn't know about,
+// keyed by their name in the file
+func (c Channel) Extra() map[string]interface{} {
+	extra := make(map[string]interface{}, len(c.remnant))
+	for key, value := range c.remnant {
+		if value != nil {
+			extra[key] = value
+		}
+	}
+	return extra
+}
+
+// ChannelImagePath returns where the profile image of the channel is saved
+func ChannelImagePath(slug string, projectRoot string) string {
+	return path.Join(projectRoot, fmt.Sprintf("/static/img/channels/%s.jpg", slug))
+}
+
 // MarshalYAML handles the well defined channel details as well as any other fields specified
 func (c Channel) MarshalYAML() (interface{}, error) {
 	values := map[string]interface{}{}
@@ -44,11 +161,14 @@ func (c Channel) MarshalYAML() (interface{}, error) {
 	values["name"] = c.Name
 	values["slug"] = c.Slug
 	values["permalink"] = c.Permalink
+	if len(c.Aliases) > 0 {
+		values["aliases"] = c.Aliases
+	}
 	if len(c.Providers) > 0 {
 		values["providers"] = c.Providers
 	}
-	if len(c.Tags) > 0 {
-		values["tags"] = c.Tags
+	if tags := NormalizeTags(c.Tags); len(tags) > 0 {
+		values["tags"] = tags
 	}
 
 	for key, value := range c.remnant {
@@ -57,7 +177,21 @@ func (c Channel) MarshalYAML() (interface{}, error) {
 		}
 	}
 
-	return values, nil
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// Like every data file, the schema version comes first
+	doc := make(yaml.MapSlice, 0, len(keys)+1)
+	if c.SchemaVersion > 0 {
+		doc = append(doc, yaml.MapItem{Key: schemaVersionKey, Value: c.SchemaVersion})
+	}
+	for _, key := range keys {
+		doc = append(doc, yaml.MapItem{Key: key, Value: values[key]})
+	}
+	return doc, nil
 }
 
 // UnmarshalYAML handles the well defined channel details as well as any other fields specified
@@ -92,18 +226,36 @@ func (c *Channel) UnmarshalYAML(unmarshal func(interface{}) error) error {
 				return fmt.Errorf("error parsing permalink: '%s', %T is not a string", value, value)
 			}
 			channel.Permalink = permalink
+		case schemaVersionKey:
+			version, ok := value.(int)
+			if !ok {
+				return fmt.Errorf("error parsing schema_version: '%v', %T is not a number", value, value)
+			}
+			channel.SchemaVersion = version
+		case "aliases":
+			aliases, err := unmarshalStrings(value)
+			if err != nil {
+				return fmt.Errorf("error parsing aliases: %v", err)
+			}
+			channel.Aliases = aliases
 		case "tags":
 			// Handle non array tags
-			if tags, ok := value.(string); ok {
-				channel.Tags = []interface{}{tags}
+			if tag, ok := value.(string); ok {
+				channel.Tags = NormalizeTags([]string{tag})
 				continue
 			}
 
-			tags, ok := value.([]interface{})
+			items, ok := value.([]interface{})
 			if !ok {
 				return fmt.Errorf("error parsing tags: '%s', %T is not a string", value, value)
 			}
-			channel.Tags = tags
+
+			// Tags like 2020 are parsed as numbers, but are tags all the same
+			tags := make([]string, 0, len(items))
+			for _, item := range items {
+				tags = append(tags, fmt.Sprint(item))
+			}
+			channel.Tags = NormalizeTags(tags)
 		case "providers":
 			providers, ok := value.(map[interface{}]interface{})
 			if !ok {
@@ -120,12 +272,36 @@ func (c *Channel) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	c.Name = channel.Name
 	c.Slug = channel.Slug
 	c.Permalink = channel.Permalink
+	c.Aliases = channel.Aliases
+	c.SchemaVersion = channel.SchemaVersion
 	c.Providers = channel.Providers
 	c.Tags = channel.Tags
 	c.remnant = channel.remnant
 	return nil
 }
 
+// unmarshalStrings accepts either a single string or a list of strings
+func unmarshalStrings(value interface{}) ([]string, error) {
+	if str, ok := value.(string); ok {
+		return []string{str}, nil
+	}
+
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("'%v', %T is not a list", value, value)
+	}
+
+	strs := make([]string, 0, len(items))
+	for _, item := range items {
+		str, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("'%v', %T is not a string", item, item)
+		}
+		strs = append(strs, str)
+	}
+	return strs, nil
+}
+
 // Provider is one of youtube or patreon
 type Provider struct {
 	Name        string
@@ -214,6 +390,19 @@ func unmarshalProvider(values map[interface{}]interface{}, out *Provider) error
 			}
 			provider.Subscribers = uint64(subscribers)
 			break
+		case "videos":
+			videos, ok := value.([]interface{})
+			if !ok {
+				return fmt.Errorf("error parsing videos: '%s', %T is not a list", value, value)
+			}
+			for _, video := range videos {
+				id, ok := video.(string)
+				if !ok {
+					return fmt.Errorf("error parsing video ID: '%v', %T is not a string", video, video)
+				}
+				provider.Videos = append(provider.Videos, id)
+			}
+			break
 		}
 	}
 
@@ -224,35 +413,90 @@ func unmarshalProvider(values map[interface{}]interface{}, out *Provider) error
 // ChannelList is a collection of channels
 type ChannelList map[string]Channel
 
-// LoadChannels reads all the channel definitions off disk
-func LoadChannels(dataDir string) ChannelList {
-	channelList := make(ChannelList)
+// ChannelError is a channel file that couldn't be loaded
+type ChannelError struct {
+	File string
+	Err  error
+}
+
+func (e *ChannelError) Error() string {
+	return fmt.Sprintf("%s: %v", e.File, e.Err)
+}
 
+// LoadChannels reads all the channel definitions off disk, skipping anything
+// that isn't a .yml file. Files that can't be loaded are left out of the list
+// and returned as a ChannelError each, as are files with a slug another file
+// already has, unless they are named after the slug. The list is nil if the
+// folder itself can't be read.
+func LoadChannels(dataDir string) (ChannelList, []error) {
 	files, err := ioutil.ReadDir(dataDir)
 	if err != nil {
-		log.Fatal(err)
+		return nil, []error{err}
 	}
 
+	channelList := make(ChannelList)
+	channelFiles := make(map[string]string)
+	var errs []error
 	for _, file := range files {
-		// load each yaml
+		if file.IsDir() || path.Ext(file.Name()) != ".yml" {
+			continue
+		}
 		filePath := path.Join(dataDir, file.Name())
 
-		data, err := ioutil.ReadFile(filePath)
+		channel, err := loadChannel(filePath)
 		if err != nil {
-			log.Fatalf("Error reading channel file '%s': %v", filePath, err)
+			errs = append(errs, &ChannelError{File: filePath, Err: err})
+			continue
 		}
 
-		channel := Channel{}
-
-		err = yaml.Unmarshal([]byte(data), &channel)
-		if err != nil {
-			log.Panicf("Error unmarshalling into channel: %v", err)
+		if other, ok := channelFiles[channel.Slug]; ok {
+			// The file named after the slug is the one bake saves to, so it
+			// wins over any other
+			duplicate := filePath
+			if filePath == ChannelFilePath(channel.Slug, dataDir) {
+				duplicate, other = other, filePath
+				channelList[channel.Slug] = channel
+				channelFiles[channel.Slug] = filePath
+			}
+			errs = append(errs, &ChannelError{File: duplicate, Err: fmt.Errorf("slug '%s' is already used by %s", channel.Slug, other)})
+			continue
 		}
 
 		channelList[channel.Slug] = channel
+		channelFiles[channel.Slug] = filePath
 	}
 
-	return channelList
+	return channelList, errs
+}
+
+func loadChannel(filePath string) (Channel, error) {
+	channel := Channel{file: filePath}
+
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return channel, err
+	}
+	if err := yaml.Unmarshal(data, &channel); err != nil {
+		return channel, err
+	}
+	if channel.Slug == "" {
+		return channel, fmt.Errorf("no slug")
+	}
+	return channel, nil
+}
+
+// LoadErrors joins the errors from LoadChannels into one, for callers that
+// can't carry on without every channel
+func LoadErrors(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return fmt.Errorf("couldn't load channels:\n  %s", strings.Join(msgs, "\n  "))
 }
 
 // Contains returns true if the supplied URL matches any provider's URL
@@ -267,7 +511,19 @@ func (channelList ChannelList) Find(slug string) (*Channel, bool) {
 	return &channel, ok
 }
 
-// SaveChannels saves all the channel definitions back to disk
+// TagCounts returns how many channels have each tag
+func (channelList ChannelList) TagCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, channel := range channelList {
+		for _, tag := range NormalizeTags(channel.Tags) {
+			counts[tag]++
+		}
+	}
+	return counts
+}
+
+// SaveChannels saves all the channel definitions back to disk, skipping the
+// ones that haven't changed
 func SaveChannels(channelList ChannelList, dataDir string) bool {
 	for _, channel := range channelList {
 		err := SaveChannel(&channel, dataDir)
@@ -280,16 +536,44 @@ func SaveChannels(channelList ChannelList, dataDir string) bool {
 	return true
 }
 
+// safeSlug matches slugs that can be used as a single file or folder name
+var safeSlug = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]*$`)
+
+// CheckSlug makes sure a slug is safe to name the channel's file, videos
+// folder and profile image after, so it can't point anywhere else
+func CheckSlug(slug string) error {
+	if !safeSlug.MatchString(slug) {
+		return fmt.Errorf("slug '%s' can only have letters, numbers, '-', '_' and '.', and can't start with '.'", slug)
+	}
+	return nil
+}
+
+// ChannelFilePath returns the file in dataDir a channel is saved to
+func ChannelFilePath(slug string, dataDir string) string {
+	return path.Join(dataDir, fmt.Sprintf("%s.yml", slug))
+}
+
 // SaveChannel saves an individual channel, overwriting the channel file if it
-// already exists
+// already exists. A channel loaded from dataDir is saved back to the file it
+// was loaded from, even if that isn't named after its slug. The file is left
+// alone when it already has the same content. A channel without a schema
+// version gets the current one, unless it still has legacy fields for bake
+// migrate to upgrade.
 func SaveChannel(channel *Channel, dataDir string) error {
-	filePath := path.Join(dataDir, fmt.Sprintf("%s.yml", channel.Slug))
-	log.Printf("Saving %s\n", filePath)
+	filePath := ChannelFilePath(channel.Slug, dataDir)
+	if channel.file != "" && path.Dir(channel.file) == path.Clean(dataDir) {
+		filePath = channel.file
+	}
+	channel.stampSchemaVersion()
 
 	data, err := yaml.Marshal(channel)
 	if err != nil {
 		return err
 	}
 
-	return ioutil.WriteFile(filePath, data, os.ModePerm)
+	written, err := WriteFileIfChanged(filePath, data)
+	if written && err == nil {
+		log.Printf("Saved %s\n", filePath)
+	}
+	return err
 }
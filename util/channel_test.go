@@ -1,7 +1,11 @@
 package util
 
 import (
+	"io/ioutil"
+	"os"
+	"path"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -25,6 +29,9 @@ providers:
     url: "https://www.youtube.com/user/anarchopac"
     subscribers: 15438
     description: ""
+    videos:
+    - 5sd9Wd6R_Ms
+    - Z1bGk2nQ3Lw
   patreon:
     name: "anarchopac"
     url: "https://www.patreon.com/anarchopac"
@@ -64,6 +71,7 @@ func TestChannelUnmarshalYAML_NewFormat(t *testing.T) {
 	assert.Equal(t, "anarchopac", youtubeProvider.Name)
 	assert.Equal(t, MustParseURL("https://www.youtube.com/user/anarchopac"), youtubeProvider.URL)
 	assert.Equal(t, uint64(15438), youtubeProvider.Subscribers)
+	assert.Equal(t, []string{"5sd9Wd6R_Ms", "Z1bGk2nQ3Lw"}, youtubeProvider.Videos)
 
 	patreonProvider := channel.Providers["patreon"]
 	assert.Equal(t, MustParseURL("https://www.patreon.com/anarchopac"), patreonProvider.URL)
@@ -119,3 +127,135 @@ providers:
 	assert.NoError(t, err)
 	assert.Equal(t, MustParseURL("http://youtube.com/channel/cancelled"), channel.YouTubeURL())
 }
+
+func TestChannelTagsAndProviders(t *testing.T) {
+	channel := Channel{}
+
+	err := yaml.Unmarshal([]byte(channelYAMLNewFormat), &channel)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"breadtube"}, channel.Tags)
+	assert.True(t, channel.HasTag("BreadTube"))
+	assert.False(t, channel.HasTag("gaming"))
+	assert.Equal(t, []string{"patreon", "youtube"}, channel.ProviderNames())
+}
+
+func TestChannelExtra(t *testing.T) {
+	channel := Channel{}
+
+	err := yaml.Unmarshal([]byte("slug: hbomberguy\nname: Hbomberguy\nurl: https://www.youtube.com/user/hbomberguy\nlegacy: ~\n"), &channel)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"url": "https://www.youtube.com/user/hbomberguy"}, channel.Extra())
+}
+
+func TestChannelTagsNormalized(t *testing.T) {
+	channel := Channel{}
+
+	err := yaml.Unmarshal([]byte("slug: anarchopac\ntags: [BreadTube, ' Anarchism ', breadtube, 2020]\n"), &channel)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"breadtube", "anarchism", "2020"}, channel.Tags)
+
+	assert.False(t, channel.AddTag("Anarchism"))
+	assert.True(t, channel.AddTag("Video Essays"))
+	assert.True(t, channel.RemoveTag("2020"))
+	assert.False(t, channel.RemoveTag("2020"))
+	assert.Equal(t, []string{"breadtube", "anarchism", "video essays"}, channel.Tags)
+
+	channel.Tags = append(channel.Tags, "BREADTUBE")
+	data, err := yaml.Marshal(channel)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "tags:\n- breadtube\n- anarchism\n- video essays\n")
+}
+
+func TestChannelListTagCounts(t *testing.T) {
+	channels := ChannelList{
+		"anarchopac":  Channel{Slug: "anarchopac", Tags: []string{"breadtube", "anarchism"}},
+		"angiespeaks": Channel{Slug: "angiespeaks", Tags: []string{"BreadTube"}},
+	}
+	assert.Equal(t, map[string]int{"breadtube": 2, "anarchism": 1}, channels.TagCounts())
+}
+
+func TestLoadChannels(t *testing.T) {
+	projectRoot := writeProject(t, map[string]string{
+		"data/channels/anarchopac.yml":          "name: anarchopac\nslug: anarchopac\n",
+		"data/channels/a-copy.yml":              "name: anarchopac copy\nslug: anarchopac\n",
+		"data/channels/angiespeaks.yml":         "name: Angie Speaks\nslug: angiespeaks\n",
+		"data/channels/angiespeaks-old.yml":     "name: Angie Speaks\nslug: angiespeaks\n",
+		"data/channels/broken.yml":              "name: Broken\nslug: [broken\n",
+		"data/channels/noslug.yml":              "name: No Slug\n",
+		"data/channels/README.md":               "not a channel",
+		"data/channels/drafts/contrapoints.yml": "name: ContraPoints\nslug: contrapoints\n",
+	})
+	defer os.RemoveAll(projectRoot)
+	dataDir := path.Join(projectRoot, "data/channels")
+
+	channels, errs := LoadChannels(dataDir)
+	assert.Len(t, channels, 2)
+	channel, ok := channels.Find("anarchopac")
+	require.True(t, ok)
+	assert.Equal(t, "anarchopac", channel.Name)
+
+	var msgs []string
+	for _, err := range errs {
+		require.IsType(t, &ChannelError{}, err)
+		msgs = append(msgs, err.Error())
+	}
+	assert.Equal(t, []string{
+		dataDir + "/a-copy.yml: slug 'anarchopac' is already used by " + dataDir + "/anarchopac.yml",
+		dataDir + "/angiespeaks-old.yml: slug 'angiespeaks' is already used by " + dataDir + "/angiespeaks.yml",
+		dataDir + "/broken.yml: yaml: line 2: did not find expected ',' or ']'",
+		dataDir + "/noslug.yml: no slug",
+	}, msgs)
+}
+
+func TestLoadChannelsMissingFolder(t *testing.T) {
+	channels, errs := LoadChannels("/nonexistent/data/channels")
+	assert.Nil(t, channels)
+	assert.Len(t, errs, 1)
+}
+
+func TestSaveChannelSkipsUnchanged(t *testing.T) {
+	projectRoot := writeProject(t, map[string]string{"data/channels/README.md": "channels"})
+	defer os.RemoveAll(projectRoot)
+	dataDir := path.Join(projectRoot, "data/channels")
+	filePath := ChannelFilePath("anarchopac", dataDir)
+
+	channel := &Channel{Name: "anarchopac", Slug: "anarchopac", Tags: []string{"breadtube"}}
+	require.NoError(t, SaveChannel(channel, dataDir))
+
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	require.NoError(t, os.Chtimes(filePath, past, past))
+	require.NoError(t, SaveChannel(channel, dataDir))
+	info, err := os.Stat(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, past, info.ModTime(), "an unchanged channel shouldn't be written")
+
+	channel.AddTag("anarchism")
+	require.NoError(t, SaveChannel(channel, dataDir))
+	data, err := ioutil.ReadFile(filePath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "- anarchism\n")
+}
+
+func TestSaveChannelToLoadedFile(t *testing.T) {
+	projectRoot := writeProject(t, map[string]string{
+		"data/channels/Anarchopac.yml": "name: anarchopac\nslug: anarchopac\n",
+	})
+	defer os.RemoveAll(projectRoot)
+	dataDir := path.Join(projectRoot, "data/channels")
+
+	channels, errs := LoadChannels(dataDir)
+	require.Empty(t, errs)
+	channel, ok := channels.Find("anarchopac")
+	require.True(t, ok)
+	channel.AddTag("breadtube")
+	require.NoError(t, SaveChannel(channel, dataDir))
+
+	channels, errs = LoadChannels(dataDir)
+	require.Empty(t, errs)
+	channel, ok = channels.Find("anarchopac")
+	require.True(t, ok)
+	assert.Equal(t, []string{"breadtube"}, channel.Tags)
+	assert.Equal(t, path.Join(dataDir, "Anarchopac.yml"), channel.file)
+	_, err := os.Stat(ChannelFilePath("anarchopac", dataDir))
+	assert.True(t, os.IsNotExist(err))
+}
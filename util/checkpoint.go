@@ -0,0 +1,114 @@
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ChannelProgress is how far listing a channel's videos got, PageToken is the
+// next page to fetch and Videos holds the IDs from every page before it
+type ChannelProgress struct {
+	PageToken string   `yaml:"page_token"`
+	Videos    []string `yaml:"videos,omitempty"`
+}
+
+// Checkpoint records the progress of a channel update so an interrupted run
+// can be resumed. Every change is written straight to disk, and it is safe
+// for concurrent use.
+type Checkpoint struct {
+	Done     []string                   `yaml:"done"`
+	Channels map[string]ChannelProgress `yaml:"channels,omitempty"`
+
+	path string
+	mu   sync.Mutex
+}
+
+// NewCheckpoint returns an empty checkpoint that is saved to filePath
+func NewCheckpoint(filePath string) *Checkpoint {
+	return &Checkpoint{Channels: make(map[string]ChannelProgress), path: filePath}
+}
+
+// LoadCheckpoint reads a checkpoint previously saved to filePath
+func LoadCheckpoint(filePath string) (*Checkpoint, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoint := NewCheckpoint(filePath)
+	if err := yaml.Unmarshal(data, checkpoint); err != nil {
+		return nil, err
+	}
+	if checkpoint.Channels == nil {
+		checkpoint.Channels = make(map[string]ChannelProgress)
+	}
+
+	return checkpoint, nil
+}
+
+// Path returns the file the checkpoint is saved to
+func (c *Checkpoint) Path() string {
+	return c.path
+}
+
+// IsDone reports whether the channel finished updating
+func (c *Checkpoint) IsDone(slug string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, done := range c.Done {
+		if done == slug {
+			return true
+		}
+	}
+	return false
+}
+
+// Progress returns how far listing the channel's videos got
+func (c *Checkpoint) Progress(slug string) ChannelProgress {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Channels[slug]
+}
+
+// SetProgress records how far listing the channel's videos got
+func (c *Checkpoint) SetProgress(slug string, progress ChannelProgress) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Channels[slug] = progress
+	return c.save()
+}
+
+// MarkDone records that the channel finished updating
+func (c *Checkpoint) MarkDone(slug string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.Channels, slug)
+	c.Done = append(c.Done, slug)
+	return c.save()
+}
+
+// Remove deletes the checkpoint file, once there is nothing left to resume
+func (c *Checkpoint) Remove() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	err := os.Remove(c.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (c *Checkpoint) save() error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return WriteFile(c.path, data)
+}
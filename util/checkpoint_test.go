@@ -0,0 +1,35 @@
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bake")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filePath := path.Join(dir, "checkpoint.yml")
+	checkpoint := NewCheckpoint(filePath)
+	require.NoError(t, checkpoint.SetProgress("anarchopac", ChannelProgress{PageToken: "CAIQAA", Videos: []string{"a", "b"}}))
+	require.NoError(t, checkpoint.SetProgress("angiespeaks", ChannelProgress{PageToken: "CAUQAA"}))
+	require.NoError(t, checkpoint.MarkDone("angiespeaks"))
+
+	loaded, err := LoadCheckpoint(filePath)
+	require.NoError(t, err)
+	assert.True(t, loaded.IsDone("angiespeaks"))
+	assert.False(t, loaded.IsDone("anarchopac"))
+	assert.Equal(t, ChannelProgress{PageToken: "CAIQAA", Videos: []string{"a", "b"}}, loaded.Progress("anarchopac"))
+	assert.Equal(t, ChannelProgress{}, loaded.Progress("angiespeaks"))
+
+	require.NoError(t, loaded.Remove())
+	_, err = LoadCheckpoint(filePath)
+	assert.True(t, os.IsNotExist(err))
+	assert.NoError(t, loaded.Remove())
+}
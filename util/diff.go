@@ -0,0 +1,129 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 3
+
+// Diff returns a unified diff of the changes from before to after, with name
+// as the file name in the header. It is empty when nothing changed.
+func Diff(name string, before, after []byte) string {
+	a, b := splitLines(before), splitLines(after)
+	edits := diffLines(a, b)
+
+	out := bytes.Buffer{}
+	for start := 0; start < len(edits); {
+		// Find the next change, then extend the hunk until there is more
+		// unchanged context between changes than would be shown
+		first := start
+		for first < len(edits) && edits[first].op == ' ' {
+			first++
+		}
+		if first == len(edits) {
+			break
+		}
+
+		last := first
+		for i := first; i < len(edits); i++ {
+			if edits[i].op != ' ' {
+				last = i
+			} else if i-last > 2*diffContext {
+				break
+			}
+		}
+
+		from, to := max(first-diffContext, 0), min(last+diffContext+1, len(edits))
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", name, name)
+		}
+
+		aStart, aLines, bStart, bLines := edits[from].a, 0, edits[from].b, 0
+		for _, edit := range edits[from:to] {
+			if edit.op != '+' {
+				aLines++
+			}
+			if edit.op != '-' {
+				bLines++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", aStart+1, aLines, bStart+1, bLines)
+		for _, edit := range edits[from:to] {
+			fmt.Fprintf(&out, "%c%s\n", edit.op, edit.line)
+		}
+
+		start = to
+	}
+
+	return out.String()
+}
+
+// lineEdit is a single line of a diff. a and b are the indexes of the line, or
+// of the next line, in each version.
+type lineEdit struct {
+	op   byte
+	line string
+	a, b int
+}
+
+// diffLines works out the edits from a to b using their longest common
+// subsequence of lines
+func diffLines(a, b []string) []lineEdit {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var edits []lineEdit
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			edits = append(edits, lineEdit{' ', a[i], i, j})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, lineEdit{'-', a[i], i, j})
+			i++
+		default:
+			edits = append(edits, lineEdit{'+', b[j], i, j})
+			j++
+		}
+	}
+	return edits
+}
+
+func splitLines(data []byte) []string {
+	text := strings.TrimSuffix(string(data), "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
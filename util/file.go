@@ -0,0 +1,61 @@
+package util
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+)
+
+// dataFileMode lets everyone read the files bake saves, and only their owner
+// write them
+const dataFileMode = 0644
+
+// WriteFile saves data to filePath without ever leaving it half written. The
+// data is written to a temporary file next to it, synced to disk and then
+// renamed over filePath, so a crash part way through leaves the old file as
+// it was.
+func WriteFile(filePath string, data []byte) error {
+	return WriteFileFrom(filePath, bytes.NewReader(data))
+}
+
+// WriteFileIfChanged is WriteFile, except that a file already holding data is
+// left alone. It reports whether the file was written.
+func WriteFileIfChanged(filePath string, data []byte) (bool, error) {
+	if existing, err := ioutil.ReadFile(filePath); err == nil && bytes.Equal(existing, data) {
+		return false, nil
+	}
+	return true, WriteFile(filePath, data)
+}
+
+// WriteFileFrom is WriteFile for data read from r, such as a download. If
+// reading fails, filePath is left as it was.
+func WriteFileFrom(filePath string, r io.Reader) error {
+	// The temporary file is hidden and doesn't end in .yml, so nothing
+	// reading the data folders picks it up
+	tmp, err := ioutil.TempFile(path.Dir(filePath), "."+path.Base(filePath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := writeTemp(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filePath)
+}
+
+func writeTemp(tmp *os.File, r io.Reader) error {
+	if err := tmp.Chmod(dataFileMode); err != nil {
+		return err
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		return err
+	}
+	return tmp.Sync()
+}
@@ -0,0 +1,84 @@
+package util
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bake")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filePath := path.Join(dir, "anarchopac.yml")
+
+	require.NoError(t, ioutil.WriteFile(filePath, []byte("old"), 0600))
+	require.NoError(t, WriteFile(filePath, []byte("name: anarchopac\n")))
+
+	data, err := ioutil.ReadFile(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, "name: anarchopac\n", string(data))
+
+	info, err := os.Stat(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 1, "the temporary file should be gone")
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func TestWriteFileFromFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bake")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filePath := path.Join(dir, "anarchopac.jpg")
+
+	require.NoError(t, ioutil.WriteFile(filePath, []byte("old"), 0644))
+	err = WriteFileFrom(filePath, io.MultiReader(strings.NewReader("new"), failingReader{}))
+	assert.EqualError(t, err, "connection reset")
+
+	data, err := ioutil.ReadFile(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, "old", string(data))
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 1, "the temporary file should be gone")
+}
+
+func TestWriteFileIfChanged(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bake")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filePath := path.Join(dir, "5sd9Wd6R_Ms.yml")
+
+	written, err := WriteFileIfChanged(filePath, []byte("id: 5sd9Wd6R_Ms\n"))
+	require.NoError(t, err)
+	assert.True(t, written)
+
+	written, err = WriteFileIfChanged(filePath, []byte("id: 5sd9Wd6R_Ms\n"))
+	require.NoError(t, err)
+	assert.False(t, written)
+
+	written, err = WriteFileIfChanged(filePath, []byte("id: 5sd9Wd6R_Ms\ntitle: Election special\n"))
+	require.NoError(t, err)
+	assert.True(t, written)
+
+	data, err := ioutil.ReadFile(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, "id: 5sd9Wd6R_Ms\ntitle: Election special\n", string(data))
+}
@@ -0,0 +1,240 @@
+package util
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// MergeChannels folds the channel dropSlug into keepSlug, combining their
+// providers, tags and other fields, moving the dropped channel's videos across
+// and deleting it. Where both channels have the same field, or the same field
+// of a provider, the kept channel's wins, and the videos of a provider both
+// have are combined. The dropped channel's aliases are kept, and with
+// keepAlias so is its permalink, in the aliases of the merged channel.
+//
+// Nothing is changed if a video exists for both channels with different
+// details, and if any step fails the steps already taken are undone.
+func MergeChannels(keepSlug, dropSlug, projectRoot string, keepAlias bool) error {
+	if keepSlug == dropSlug {
+		return fmt.Errorf("can't merge channel '%s' into itself", keepSlug)
+	}
+
+	dataDir := path.Join(projectRoot, "/data/channels")
+	channels, errs := LoadChannels(dataDir)
+	if len(errs) > 0 {
+		return LoadErrors(errs)
+	}
+
+	keep, ok := channels.Find(keepSlug)
+	if !ok {
+		return fmt.Errorf("couldn't find channel with slug '%s'", keepSlug)
+	}
+	drop, ok := channels.Find(dropSlug)
+	if !ok {
+		return fmt.Errorf("couldn't find channel with slug '%s'", dropSlug)
+	}
+
+	keep.merge(*drop, keepAlias)
+	keep.stampSchemaVersion()
+	data, err := yaml.Marshal(keep)
+	if err != nil {
+		return err
+	}
+
+	keepVideos, dropVideos := ChannelVideoFolder(keepSlug, projectRoot), ChannelVideoFolder(dropSlug, projectRoot)
+	keepImage, dropImage := ChannelImagePath(keepSlug, projectRoot), ChannelImagePath(dropSlug, projectRoot)
+
+	t := fileTransaction{}
+	defer t.cleanup()
+
+	if _, err := os.Stat(dropVideos); err == nil {
+		staged := ChannelVideoFolder("."+dropSlug+".staged", projectRoot)
+		t.temporary = append(t.temporary, staged)
+		if err := copyVideos(dropVideos, staged, keepSlug); err != nil {
+			return err
+		}
+
+		moves, err := videoMoves(staged, keepVideos)
+		if err != nil {
+			return err
+		}
+
+		if len(moves) > 0 {
+			if _, err := os.Stat(keepVideos); os.IsNotExist(err) {
+				if err := t.mkdir(keepVideos); err != nil {
+					return t.rollback(err)
+				}
+			}
+		}
+		for _, name := range moves {
+			if err := t.move(path.Join(staged, name), path.Join(keepVideos, name)); err != nil {
+				return t.rollback(err)
+			}
+		}
+
+		if err := t.discard(dropVideos); err != nil {
+			return t.rollback(err)
+		}
+	}
+
+	if err := t.write(keep.file, data); err != nil {
+		return t.rollback(err)
+	}
+
+	if _, err := os.Stat(dropImage); err == nil {
+		if _, err := os.Stat(keepImage); os.IsNotExist(err) {
+			err = t.move(dropImage, keepImage)
+		} else {
+			err = t.discard(dropImage)
+		}
+		if err != nil {
+			return t.rollback(err)
+		}
+	}
+
+	if err := t.discard(drop.file); err != nil {
+		return t.rollback(err)
+	}
+
+	t.commit()
+	return nil
+}
+
+// merge adds the providers, tags, aliases and other fields of other that the
+// channel doesn't have already, combining the entries of providers both have
+func (c *Channel) merge(other Channel, keepAlias bool) {
+	if c.Providers == nil {
+		c.Providers = make(map[string]Provider)
+	}
+	for name, provider := range other.Providers {
+		if kept, ok := c.Providers[name]; ok {
+			provider = kept.merge(provider)
+		}
+		c.Providers[name] = provider
+	}
+
+	for _, tag := range other.Tags {
+		c.AddTag(tag)
+	}
+
+	if c.remnant == nil {
+		c.remnant = make(map[string]interface{})
+	}
+	for key, value := range other.remnant {
+		if _, ok := c.remnant[key]; !ok {
+			c.remnant[key] = value
+		}
+	}
+
+	// The other channel's aliases are redirects the site already relies on,
+	// so they are always kept. Its own permalink is only kept with keepAlias.
+	aliases := append([]string(nil), other.Aliases...)
+	if keepAlias {
+		permalink := other.Permalink
+		if permalink == "" {
+			permalink = other.Slug
+		}
+		aliases = append(aliases, permalink)
+	}
+	for _, alias := range aliases {
+		if alias != c.Permalink && !containsString(c.Aliases, alias) {
+			c.Aliases = append(c.Aliases, alias)
+		}
+	}
+}
+
+// merge combines two entries for the same provider, keeping p's fields where
+// both are set and every video of either
+func (p Provider) merge(other Provider) Provider {
+	if p.Name == "" {
+		p.Name = other.Name
+	}
+	if p.Slug == "" {
+		p.Slug = other.Slug
+	}
+	if p.URL == nil {
+		p.URL = other.URL
+	}
+	if p.Description == "" {
+		p.Description = other.Description
+	}
+	if p.Subscribers == 0 {
+		p.Subscribers = other.Subscribers
+	}
+
+	videos := append([]string(nil), p.Videos...)
+	for _, id := range other.Videos {
+		if !containsString(videos, id) {
+			videos = append(videos, id)
+		}
+	}
+	p.Videos = videos
+	return p
+}
+
+// videoMoves returns the videos in from that aren't in to already. It fails
+// when a video is in both with different details, listing every such video.
+func videoMoves(from, to string) ([]string, error) {
+	files, err := ioutil.ReadDir(from)
+	if err != nil {
+		return nil, err
+	}
+
+	var moves, conflicts []string
+	for _, file := range files {
+		existing, err := ioutil.ReadFile(path.Join(to, file.Name()))
+		if os.IsNotExist(err) {
+			moves = append(moves, file.Name())
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		incoming, err := ioutil.ReadFile(path.Join(from, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		same, err := sameVideo(existing, incoming)
+		if err != nil {
+			return nil, fmt.Errorf("error comparing '%s': %v", file.Name(), err)
+		}
+		if !same {
+			conflicts = append(conflicts, file.Name())
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("videos differ between the two channels: %s", strings.Join(conflicts, ", "))
+	}
+	return moves, nil
+}
+
+// sameVideo reports whether two video files have the same details, however
+// they are formatted
+func sameVideo(a, b []byte) (bool, error) {
+	var videoA, videoB interface{}
+	if err := yaml.Unmarshal(a, &videoA); err != nil {
+		return false, err
+	}
+	if err := yaml.Unmarshal(b, &videoB); err != nil {
+		return false, err
+	}
+	return reflect.DeepEqual(videoA, videoB), nil
+}
+
+func containsString(strs []string, s string) bool {
+	for _, str := range strs {
+		if str == s {
+			return true
+		}
+	}
+	return false
+}
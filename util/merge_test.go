@@ -0,0 +1,152 @@
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeChannels(t *testing.T) {
+	projectRoot := writeProject(t, map[string]string{
+		"data/channels/anarchopac.yml": `name: anarchopac
+slug: anarchopac
+permalink: anarchopac
+providers:
+  youtube:
+    url: https://www.youtube.com/user/anarchopac
+    subscribers: 15438
+tags: [breadtube]
+`,
+		"data/channels/anarchopac2.yml": `name: anarchopac
+slug: anarchopac2
+permalink: anarchopac2
+providers:
+  youtube:
+    url: https://www.youtube.com/channel/UCUtloyZ_Iu4BJekIqPLc_fQ
+  patreon:
+    url: https://www.patreon.com/anarchopac
+tags: [Breadtube, anarchism]
+twitter: anarchopac
+`,
+		"data/videos/anarchopac/5sd9Wd6R_Ms.yml":  "id: 5sd9Wd6R_Ms\nchannel: anarchopac\n",
+		"data/videos/anarchopac2/5sd9Wd6R_Ms.yml": "channel: anarchopac2\nid: 5sd9Wd6R_Ms\n",
+		"data/videos/anarchopac2/Z1bGk2nQ3Lw.yml": "id: Z1bGk2nQ3Lw\nchannel: anarchopac2\n",
+		"static/img/channels/anarchopac2.jpg":     "jpeg",
+	})
+	defer os.RemoveAll(projectRoot)
+
+	require.NoError(t, MergeChannels("anarchopac", "anarchopac2", projectRoot, true))
+
+	channels, errs := LoadChannels(path.Join(projectRoot, "data/channels"))
+	require.Empty(t, errs)
+	assert.False(t, channels.Contains("anarchopac2"))
+	channel, ok := channels.Find("anarchopac")
+	require.True(t, ok)
+	assert.Equal(t, []string{"patreon", "youtube"}, channel.ProviderNames())
+	assert.Equal(t, "https://www.youtube.com/user/anarchopac", channel.YouTubeURL().String())
+	assert.Equal(t, []string{"breadtube", "anarchism"}, channel.Tags)
+	assert.Equal(t, map[string]interface{}{"twitter": "anarchopac"}, channel.Extra())
+	assert.Equal(t, []string{"anarchopac2"}, channel.Aliases)
+
+	videos, err := GetCreatorVideos("anarchopac", projectRoot)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"5sd9Wd6R_Ms", "Z1bGk2nQ3Lw"}, videos)
+
+	data, err := ioutil.ReadFile(path.Join(projectRoot, "data/videos/anarchopac/Z1bGk2nQ3Lw.yml"))
+	require.NoError(t, err)
+	assert.Equal(t, "id: Z1bGk2nQ3Lw\nchannel: anarchopac\n", string(data))
+
+	assert.FileExists(t, path.Join(projectRoot, "static/img/channels/anarchopac.jpg"))
+	for _, dropped := range []string{"data/videos/anarchopac2", "data/videos/.anarchopac2.staged", "static/img/channels/anarchopac2.jpg"} {
+		_, err := os.Stat(path.Join(projectRoot, dropped))
+		assert.True(t, os.IsNotExist(err), dropped)
+	}
+}
+
+func TestMergeChannels_Conflict(t *testing.T) {
+	projectRoot := writeProject(t, map[string]string{
+		"data/channels/anarchopac.yml":            "name: anarchopac\nslug: anarchopac\n",
+		"data/channels/anarchopac2.yml":           "name: anarchopac\nslug: anarchopac2\n",
+		"data/videos/anarchopac/5sd9Wd6R_Ms.yml":  "id: 5sd9Wd6R_Ms\ntitle: Election special\nchannel: anarchopac\n",
+		"data/videos/anarchopac2/5sd9Wd6R_Ms.yml": "id: 5sd9Wd6R_Ms\ntitle: Something else\nchannel: anarchopac2\n",
+		"data/videos/anarchopac2/Z1bGk2nQ3Lw.yml": "id: Z1bGk2nQ3Lw\nchannel: anarchopac2\n",
+	})
+	defer os.RemoveAll(projectRoot)
+
+	err := MergeChannels("anarchopac", "anarchopac2", projectRoot, false)
+	assert.EqualError(t, err, "videos differ between the two channels: 5sd9Wd6R_Ms.yml")
+
+	channels, errs := LoadChannels(path.Join(projectRoot, "data/channels"))
+	require.Empty(t, errs)
+	assert.True(t, channels.Contains("anarchopac2"))
+	videos, err := GetCreatorVideos("anarchopac", projectRoot)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"5sd9Wd6R_Ms"}, videos)
+	assert.FileExists(t, path.Join(projectRoot, "data/videos/anarchopac2/Z1bGk2nQ3Lw.yml"))
+}
+
+func TestMergeChannelsSameProvider(t *testing.T) {
+	keep := Channel{Slug: "anarchopac", Providers: map[string]Provider{
+		"youtube": {
+			URL:         MustParseURL("https://www.youtube.com/user/anarchopac"),
+			Subscribers: 15438,
+			Videos:      []string{"5sd9Wd6R_Ms", "Z1bGk2nQ3Lw"},
+		},
+	}}
+	drop := Channel{Slug: "anarchopac2", Providers: map[string]Provider{
+		"youtube": {
+			Name:        "anarchopac",
+			URL:         MustParseURL("https://www.youtube.com/channel/UCUtloyZ_Iu4BJekIqPLc_fQ"),
+			Description: "Anarchism",
+			Subscribers: 15000,
+			Videos:      []string{"Z1bGk2nQ3Lw", "qR7tY8uI9oP"},
+		},
+	}}
+
+	keep.merge(drop, false)
+	assert.Equal(t, Provider{
+		Name:        "anarchopac",
+		URL:         MustParseURL("https://www.youtube.com/user/anarchopac"),
+		Description: "Anarchism",
+		Subscribers: 15438,
+		Videos:      []string{"5sd9Wd6R_Ms", "Z1bGk2nQ3Lw", "qR7tY8uI9oP"},
+	}, keep.Providers["youtube"])
+}
+
+func TestMergeChannels_FilesNotNamedAfterSlug(t *testing.T) {
+	projectRoot := writeProject(t, map[string]string{
+		"data/channels/Anarchopac.yml":  "name: anarchopac\nslug: anarchopac\ntags: [breadtube]\n",
+		"data/channels/Anarchopac2.yml": "name: anarchopac\nslug: anarchopac2\ntags: [anarchism]\n",
+	})
+	defer os.RemoveAll(projectRoot)
+	dataDir := path.Join(projectRoot, "data/channels")
+
+	require.NoError(t, MergeChannels("anarchopac", "anarchopac2", projectRoot, false))
+
+	channels, errs := LoadChannels(dataDir)
+	require.Empty(t, errs)
+	assert.Len(t, channels, 1)
+	channel, ok := channels.Find("anarchopac")
+	require.True(t, ok)
+	assert.Equal(t, []string{"breadtube", "anarchism"}, channel.Tags)
+
+	files, err := ioutil.ReadDir(dataDir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "Anarchopac.yml", files[0].Name())
+}
+
+func TestMergeChannelsKeepsAliases(t *testing.T) {
+	keep := Channel{Slug: "anarchopac", Permalink: "anarchopac", Aliases: []string{"anarcho-pac"}}
+	drop := Channel{Slug: "anarchopac2", Permalink: "anarchopac2", Aliases: []string{"anarchopac-old", "anarchopac"}}
+
+	keep.merge(drop, false)
+	assert.Equal(t, []string{"anarcho-pac", "anarchopac-old"}, keep.Aliases)
+
+	keep.merge(drop, true)
+	assert.Equal(t, []string{"anarcho-pac", "anarchopac-old", "anarchopac2"}, keep.Aliases)
+}
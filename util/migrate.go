@@ -0,0 +1,302 @@
+package util
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	// ChannelSchemaVersion is the version of the channel file format bake writes
+	//
+	//	1: url, description and subscribers at the top level of the file
+	//	2: the details of each provider kept under providers
+	ChannelSchemaVersion = 2
+	// VideoSchemaVersion is the version of the video file format bake writes
+	VideoSchemaVersion = 1
+)
+
+// schemaVersionKey is the field every data file keeps its version in, files
+// without it are version 1
+const schemaVersionKey = "schema_version"
+
+// Migrations holds the migrations for every kind of data file in a project
+var Migrations = loadMigrations()
+
+func loadMigrations() *MigrationRegistry {
+	registry := NewMigrationRegistry()
+	registry.AddKind("channels", "data/channels/*.yml")
+	registry.AddKind("videos", "data/videos/*/*.yml")
+
+	registry.Register("channels", Migration{
+		From:        1,
+		Description: "move the top level url, description and subscribers into providers.youtube",
+		Apply:       channelMigration(upgradeLegacyChannel),
+	})
+
+	return registry
+}
+
+// Migration upgrades a data file from one schema version to the next
+type Migration struct {
+	// From is the version the migration upgrades from, to From+1
+	From        int
+	Description string
+	// Apply changes the file, which is decoded keeping the order of its keys
+	Apply func(doc yaml.MapSlice) (yaml.MapSlice, error)
+}
+
+// dataKind is a kind of data file, along with the migrations for it
+type dataKind struct {
+	// pattern matches the kind's files, relative to the project root
+	pattern    string
+	migrations map[int]Migration
+}
+
+// MigrationRegistry holds the kinds of data file in a project and the
+// migrations that bring each of them up to date
+type MigrationRegistry struct {
+	kinds map[string]*dataKind
+	order []string
+}
+
+// NewMigrationRegistry returns a registry without any kinds of data file
+func NewMigrationRegistry() *MigrationRegistry {
+	return &MigrationRegistry{kinds: make(map[string]*dataKind)}
+}
+
+// AddKind adds a kind of data file, found with the glob pattern relative to
+// the project root
+func (r *MigrationRegistry) AddKind(kind, pattern string) {
+	r.kinds[kind] = &dataKind{pattern: pattern, migrations: make(map[int]Migration)}
+	r.order = append(r.order, kind)
+}
+
+// Register adds the next migration for a kind of data file. Migrations must be
+// registered in order, each one upgrading from the version the last upgraded to.
+func (r *MigrationRegistry) Register(kind string, migration Migration) {
+	k, ok := r.kinds[kind]
+	if !ok {
+		panic(fmt.Sprintf("unknown kind of data file '%s'", kind))
+	}
+	if migration.From != r.Version(kind) {
+		panic(fmt.Sprintf("migration for %s from version %d doesn't follow version %d", kind, migration.From, r.Version(kind)))
+	}
+	k.migrations[migration.From] = migration
+}
+
+// Kinds returns the kinds of data file in the order they were added
+func (r *MigrationRegistry) Kinds() []string {
+	return append([]string(nil), r.order...)
+}
+
+// Version returns the current version of a kind of data file
+func (r *MigrationRegistry) Version(kind string) int {
+	k, ok := r.kinds[kind]
+	if !ok {
+		return 0
+	}
+	return len(k.migrations) + 1
+}
+
+// Files returns the data files of a kind in the project, in order
+func (r *MigrationRegistry) Files(kind, projectRoot string) ([]string, error) {
+	k, ok := r.kinds[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown kind of data file '%s'", kind)
+	}
+
+	files, err := filepath.Glob(filepath.Join(projectRoot, k.pattern))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Migrate upgrades the contents of a data file to the current version of its
+// kind, returning the upgraded contents and the description of each migration
+// applied. Files already at the current version are returned unchanged.
+func (r *MigrationRegistry) Migrate(kind string, data []byte) ([]byte, []string, error) {
+	k, ok := r.kinds[kind]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown kind of data file '%s'", kind)
+	}
+
+	doc := yaml.MapSlice{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, err
+	}
+
+	version, stamped, err := schemaVersion(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	current := r.Version(kind)
+	if version > current {
+		return nil, nil, fmt.Errorf("schema version %d is newer than this version of bake supports, %d", version, current)
+	}
+	if version == current && stamped {
+		return data, nil, nil
+	}
+
+	var applied []string
+	for ; version < current; version++ {
+		migration := k.migrations[version]
+		doc, err = migration.Apply(doc)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error migrating from version %d: %v", version, err)
+		}
+		applied = append(applied, migration.Description)
+	}
+
+	migrated, err := yaml.Marshal(setSchemaVersion(doc, current))
+	if err != nil {
+		return nil, nil, err
+	}
+	return migrated, applied, nil
+}
+
+// schemaVersion returns the version of a data file, and whether it says so
+func schemaVersion(doc yaml.MapSlice) (int, bool, error) {
+	for _, item := range doc {
+		if item.Key != schemaVersionKey {
+			continue
+		}
+		version, ok := item.Value.(int)
+		if !ok || version < 1 {
+			return 0, false, fmt.Errorf("error parsing %s: '%v' is not a version", schemaVersionKey, item.Value)
+		}
+		return version, true, nil
+	}
+	return 1, false, nil
+}
+
+// setSchemaVersion sets the version of a data file, which always comes first
+func setSchemaVersion(doc yaml.MapSlice, version int) yaml.MapSlice {
+	stamped := yaml.MapSlice{{Key: schemaVersionKey, Value: version}}
+	for _, item := range doc {
+		if item.Key != schemaVersionKey {
+			stamped = append(stamped, item)
+		}
+	}
+	return stamped
+}
+
+// channelMigration adapts a change to a Channel into a Migration
+func channelMigration(upgrade func(c *Channel) error) func(doc yaml.MapSlice) (yaml.MapSlice, error) {
+	return func(doc yaml.MapSlice) (yaml.MapSlice, error) {
+		data, err := yaml.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+
+		channel := Channel{}
+		if err := yaml.Unmarshal(data, &channel); err != nil {
+			return nil, err
+		}
+		if err := upgrade(&channel); err != nil {
+			return nil, err
+		}
+
+		data, err = yaml.Marshal(channel)
+		if err != nil {
+			return nil, err
+		}
+
+		upgraded := yaml.MapSlice{}
+		err = yaml.Unmarshal(data, &upgraded)
+		return upgraded, err
+	}
+}
+
+// legacyChannelFields are the top level fields of version 1 channel files,
+// which belong to the YouTube provider now
+var legacyChannelFields = []string{"url", "description", "subscribers"}
+
+// stampSchemaVersion records the current version on a channel from a file that
+// didn't have one, as long as the channel is in the current format already.
+// Channels with legacy fields are left for bake migrate to upgrade.
+func (c *Channel) stampSchemaVersion() {
+	if c.SchemaVersion != 0 {
+		return
+	}
+	for _, field := range legacyChannelFields {
+		if _, ok := c.remnant[field]; ok {
+			return
+		}
+	}
+	c.SchemaVersion = ChannelSchemaVersion
+}
+
+// upgradeLegacyChannel moves the top level fields of a version 1 channel into
+// its YouTube provider. Details the YouTube provider has already are kept.
+func upgradeLegacyChannel(c *Channel) error {
+	var legacy []string
+	for _, field := range legacyChannelFields {
+		if _, ok := c.remnant[field]; ok {
+			legacy = append(legacy, field)
+		}
+	}
+	if len(legacy) == 0 {
+		return nil
+	}
+
+	youtube, ok := c.Providers["youtube"]
+	if _, hasURL := c.remnant["url"]; !ok && !hasURL {
+		return fmt.Errorf("no YouTube URL to move %s to", strings.Join(legacy, ", "))
+	}
+
+	if err := upgradeYouTube(&youtube, c.remnant); err != nil {
+		return err
+	}
+	if youtube.Name == "" {
+		youtube.Name = c.Name
+	}
+
+	if c.Providers == nil {
+		c.Providers = make(map[string]Provider)
+	}
+	c.Providers["youtube"] = youtube
+	for _, field := range legacy {
+		delete(c.remnant, field)
+	}
+	return nil
+}
+
+// upgradeYouTube fills in the details missing from the YouTube provider with
+// the top level fields of a version 1 channel
+func upgradeYouTube(youtube *Provider, fields map[string]interface{}) error {
+	if value, ok := fields["url"]; ok && youtube.URL == nil {
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("error parsing url: '%v', %T is not a string", value, value)
+		}
+		u, err := ParseURL(str)
+		if err != nil {
+			return fmt.Errorf("error parsing url: %v", err)
+		}
+		youtube.URL = u
+	}
+
+	if value, ok := fields["description"]; ok && youtube.Description == "" {
+		description, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("error parsing description: '%v', %T is not a string", value, value)
+		}
+		youtube.Description = description
+	}
+
+	if value, ok := fields["subscribers"]; ok && youtube.Subscribers == 0 {
+		subscribers, ok := value.(int)
+		if !ok || subscribers < 0 {
+			return fmt.Errorf("error parsing subscribers: '%v' is not a positive number", value)
+		}
+		youtube.Subscribers = uint64(subscribers)
+	}
+
+	return nil
+}
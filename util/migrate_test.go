@@ -0,0 +1,168 @@
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestMigrations(t *testing.T) {
+	assert.Equal(t, []string{"channels", "videos"}, Migrations.Kinds())
+	assert.Equal(t, ChannelSchemaVersion, Migrations.Version("channels"))
+	assert.Equal(t, VideoSchemaVersion, Migrations.Version("videos"))
+}
+
+func TestMigrate_OldFormatChannel(t *testing.T) {
+	data, applied, err := Migrations.Migrate("channels", []byte(channelYAMLOldFormat))
+	require.NoError(t, err)
+	assert.Len(t, applied, 1)
+	assert.Equal(t, `schema_version: 2
+name: Angie Speaks
+permalink: ""
+providers:
+  youtube:
+    name: Angie Speaks
+    slug: ""
+    url: https://www.youtube.com/channel/UCUtloyZ_Iu4BJekIqPLc_fQ
+    description: Anarchist Leftist channel with a creative and mystical flair!
+    subscribers: 8367
+slug: angiespeaks
+tags:
+- breadtube
+`, string(data))
+
+	// Migrating again changes nothing
+	again, applied, err := Migrations.Migrate("channels", data)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+	assert.Equal(t, data, again)
+}
+
+func TestMigrate_NewFormatChannel(t *testing.T) {
+	data, _, err := Migrations.Migrate("channels", []byte(channelYAMLNewFormat))
+	require.NoError(t, err)
+
+	channel := Channel{}
+	require.NoError(t, yaml.Unmarshal(data, &channel))
+	assert.Equal(t, ChannelSchemaVersion, channel.SchemaVersion)
+	assert.Empty(t, channel.Extra())
+
+	// The provider keeps its own details, the top level ones only fill the gaps
+	youtube := channel.Providers["youtube"]
+	assert.Equal(t, "https://www.youtube.com/user/anarchopac", youtube.URL.String())
+	assert.Equal(t, uint64(15438), youtube.Subscribers)
+	assert.Equal(t, "I'm a disabled pan-sexual trans woman who talks about anarchism, feminism and marxism.", youtube.Description)
+}
+
+func TestMigrate_ChannelWithoutURL(t *testing.T) {
+	_, _, err := Migrations.Migrate("channels", []byte("name: Contrapoints\nslug: contrapoints\nsubscribers: 1000\n"))
+	assert.EqualError(t, err, "error migrating from version 1: no YouTube URL to move subscribers to")
+}
+
+func TestMigrate_Video(t *testing.T) {
+	data, applied, err := Migrations.Migrate("videos", []byte("id: 5sd9Wd6R_Ms\ntitle: Election special\nchannel: anarchopac\n"))
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+	assert.Equal(t, "schema_version: 1\nid: 5sd9Wd6R_Ms\ntitle: Election special\nchannel: anarchopac\n", string(data))
+
+	_, _, err = Migrations.Migrate("videos", []byte("schema_version: 7\nid: 5sd9Wd6R_Ms\n"))
+	assert.EqualError(t, err, "schema version 7 is newer than this version of bake supports, 1")
+}
+
+func TestSaveChannelStampsSchemaVersion(t *testing.T) {
+	projectRoot := writeProject(t, map[string]string{
+		"data/channels/anarchopac.yml":  "name: anarchopac\nslug: anarchopac\n",
+		"data/channels/angiespeaks.yml": "name: Angie Speaks\nslug: angiespeaks\nurl: https://www.youtube.com/channel/UCUtloyZ_Iu4BJekIqPLc_fQ\n",
+	})
+	defer os.RemoveAll(projectRoot)
+	dataDir := path.Join(projectRoot, "data/channels")
+
+	channels, errs := LoadChannels(dataDir)
+	require.Empty(t, errs)
+	for _, slug := range []string{"anarchopac", "angiespeaks"} {
+		channel, _ := channels.Find(slug)
+		channel.AddTag("breadtube")
+		require.NoError(t, SaveChannel(channel, dataDir))
+	}
+
+	data, err := ioutil.ReadFile(ChannelFilePath("anarchopac", dataDir))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "schema_version: 2\n")
+
+	// Still in the old format, so it's left for bake migrate
+	data, err = ioutil.ReadFile(ChannelFilePath("angiespeaks", dataDir))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "schema_version")
+}
+
+func TestMigrationRegistry(t *testing.T) {
+	registry := NewMigrationRegistry()
+	registry.AddKind("playlists", "data/playlists/*.yml")
+	registry.Register("playlists", Migration{
+		From: 1,
+		Apply: func(doc yaml.MapSlice) (yaml.MapSlice, error) {
+			return append(doc, yaml.MapItem{Key: "videos", Value: []string{}}), nil
+		},
+	})
+	registry.Register("playlists", Migration{
+		From: 2,
+		Apply: func(doc yaml.MapSlice) (yaml.MapSlice, error) {
+			for i := range doc {
+				if doc[i].Key == "name" {
+					doc[i].Key = "title"
+				}
+			}
+			return doc, nil
+		},
+	})
+	assert.Equal(t, 3, registry.Version("playlists"))
+	assert.Panics(t, func() { registry.Register("playlists", Migration{From: 2}) })
+
+	data, applied, err := registry.Migrate("playlists", []byte("name: Anarchism\n"))
+	require.NoError(t, err)
+	assert.Len(t, applied, 2)
+	assert.Equal(t, "schema_version: 3\ntitle: Anarchism\nvideos: []\n", string(data))
+
+	data, _, err = registry.Migrate("playlists", []byte("schema_version: 2\nname: Anarchism\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "schema_version: 3\ntitle: Anarchism\n", string(data))
+
+	projectRoot := writeProject(t, map[string]string{
+		"data/playlists/b.yml":         "name: B\n",
+		"data/playlists/a.yml":         "name: A\n",
+		"data/playlists/c.txt":         "name: C\n",
+		"data/channels/anarchopac.yml": "slug: anarchopac\n",
+	})
+	defer os.RemoveAll(projectRoot)
+
+	files, err := registry.Files("playlists", projectRoot)
+	require.NoError(t, err)
+	assert.Equal(t, []string{path.Join(projectRoot, "data/playlists/a.yml"), path.Join(projectRoot, "data/playlists/b.yml")}, files)
+}
+
+func TestDiff(t *testing.T) {
+	before := []byte("a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n")
+	after := []byte("a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\n")
+
+	assert.Equal(t, `--- file.yml
++++ file.yml
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -8,3 +8,4 @@
+ h
+ i
+ j
++k
+`, Diff("file.yml", before, after))
+	assert.Empty(t, Diff("file.yml", before, before))
+}
@@ -0,0 +1,31 @@
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+// writeProject creates a project in a temporary folder with the given files,
+// keyed by their path in the project. Callers remove the folder with
+// defer os.RemoveAll once they are done with it.
+func writeProject(t *testing.T, files map[string]string) string {
+	projectRoot, err := ioutil.TempDir("", "bake")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, content := range files {
+		filePath := path.Join(projectRoot, name)
+		err := os.MkdirAll(path.Dir(filePath), 0755)
+		if err == nil {
+			err = ioutil.WriteFile(filePath, []byte(content), 0644)
+		}
+		if err != nil {
+			os.RemoveAll(projectRoot)
+			t.Fatal(err)
+		}
+	}
+	return projectRoot
+}
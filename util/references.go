@@ -0,0 +1,113 @@
+package util
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// FindReferences returns the data files, other than the creator's own channel
+// file and videos, that refer to the slug in one of referenceKeys, e.g.
+// playlists that list the creator
+func FindReferences(slug string, projectRoot string) ([]string, error) {
+	dataDir := path.Join(projectRoot, "/data")
+	channelFile := ChannelFilePath(slug, path.Join(dataDir, "/channels"))
+	videoFolder := ChannelVideoFolder(slug, projectRoot)
+
+	var references []string
+	err := filepath.Walk(dataDir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if filePath == videoFolder {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filePath == channelFile || !isYAMLFile(filePath) {
+			return nil
+		}
+
+		data, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+
+		var content interface{}
+		if err := yaml.Unmarshal(data, &content); err != nil {
+			return fmt.Errorf("error parsing '%s': %v", filePath, err)
+		}
+		if mentions(content, slug) {
+			references = append(references, filePath)
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	sort.Strings(references)
+	return references, err
+}
+
+func isYAMLFile(filePath string) bool {
+	ext := filepath.Ext(filePath)
+	return ext == ".yml" || ext == ".yaml"
+}
+
+// referenceKeys are the fields data files refer to a channel by its slug in,
+// either as a single slug or a list of them
+var referenceKeys = map[string]bool{
+	"aliases":  true,
+	"channel":  true,
+	"channels": true,
+	"creator":  true,
+	"creators": true,
+}
+
+// mentions reports whether value, or anything nested in it, refers to the
+// slug s in one of referenceKeys. Other fields that happen to have the slug
+// as their value, such as a tag or a title, aren't references.
+func mentions(value interface{}, s string) bool {
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if mentions(item, s) {
+				return true
+			}
+		}
+	case map[interface{}]interface{}:
+		for key, item := range v {
+			if referenceKeys[fmt.Sprint(key)] && isOrHas(item, s) {
+				return true
+			}
+			if mentions(item, s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isOrHas reports whether value is the string s, or a list with s in it
+func isOrHas(value interface{}, s string) bool {
+	if str, ok := value.(string); ok {
+		return str == s
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return false
+	}
+	for _, item := range items {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
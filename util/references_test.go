@@ -0,0 +1,43 @@
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindReferences(t *testing.T) {
+	projectRoot, err := ioutil.TempDir("", "bake")
+	require.NoError(t, err)
+	defer os.RemoveAll(projectRoot)
+
+	files := map[string]string{
+		"data/channels/anarchopac.yml":           "name: anarchopac\nslug: anarchopac\npermalink: anarchopac\n",
+		"data/channels/angiespeaks.yml":          "name: Angie Speaks\nslug: angiespeaks\n",
+		"data/videos/anarchopac/5sd9Wd6R_Ms.yml": "id: 5sd9Wd6R_Ms\nchannel: anarchopac\n",
+		"data/playlists/anarchism.yml":           "title: Anarchism\nvideos:\n- creator: anarchopac\n  id: 5sd9Wd6R_Ms\n",
+		"data/playlists/notes.txt":               "anarchopac",
+		"data/playlists/tags.yml":                "title: anarchopac\ntags: [anarchopac]\nprovider:\n  slug: anarchopac\n",
+		"data/playlists/collab.yml":              "title: Collab\ncreators: [angiespeaks, anarchopac]\n",
+	}
+	for name, content := range files {
+		filePath := path.Join(projectRoot, name)
+		require.NoError(t, os.MkdirAll(path.Dir(filePath), 0755))
+		require.NoError(t, ioutil.WriteFile(filePath, []byte(content), 0644))
+	}
+
+	references, err := FindReferences("anarchopac", projectRoot)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		path.Join(projectRoot, "data/playlists/anarchism.yml"),
+		path.Join(projectRoot, "data/playlists/collab.yml"),
+	}, references)
+
+	references, err = FindReferences("contrapoints", projectRoot)
+	require.NoError(t, err)
+	assert.Empty(t, references)
+}
@@ -0,0 +1,55 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"path"
+)
+
+// ChannelPaths returns the channel's file, profile image and videos folder,
+// leaving out any that don't exist and, with keepVideos, the videos folder
+func ChannelPaths(slug, projectRoot string, keepVideos bool) ([]string, error) {
+	dataDir := path.Join(projectRoot, "/data/channels")
+	channels, errs := LoadChannels(dataDir)
+	channel, ok := channels.Find(slug)
+	if !ok && len(errs) > 0 {
+		return nil, LoadErrors(errs)
+	}
+	if !ok {
+		return nil, fmt.Errorf("couldn't find channel with slug '%s'", slug)
+	}
+
+	candidates := []string{channel.file, ChannelImagePath(slug, projectRoot)}
+	if !keepVideos {
+		candidates = append(candidates, ChannelVideoFolder(slug, projectRoot))
+	}
+
+	var paths []string
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			paths = append(paths, candidate)
+		}
+	}
+	return paths, nil
+}
+
+// RemoveChannel deletes everything ChannelPaths returns for the channel. If
+// any of it can't be deleted, nothing is.
+func RemoveChannel(slug, projectRoot string, keepVideos bool) error {
+	paths, err := ChannelPaths(slug, projectRoot, keepVideos)
+	if err != nil {
+		return err
+	}
+
+	t := fileTransaction{}
+	defer t.cleanup()
+
+	for _, target := range paths {
+		if err := t.discard(target); err != nil {
+			return t.rollback(err)
+		}
+	}
+
+	t.commit()
+	return nil
+}
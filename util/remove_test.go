@@ -0,0 +1,56 @@
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoveChannel(t *testing.T) {
+	projectRoot := writeProject(t, map[string]string{
+		"data/channels/anarchopac.yml":           "name: anarchopac\nslug: anarchopac\n",
+		"data/videos/anarchopac/5sd9Wd6R_Ms.yml": "id: 5sd9Wd6R_Ms\nchannel: anarchopac\n",
+		"static/img/channels/anarchopac.jpg":     "jpeg",
+	})
+	defer os.RemoveAll(projectRoot)
+
+	paths, err := ChannelPaths("anarchopac", projectRoot, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		path.Join(projectRoot, "data/channels/anarchopac.yml"),
+		path.Join(projectRoot, "static/img/channels/anarchopac.jpg"),
+	}, paths)
+
+	require.NoError(t, RemoveChannel("anarchopac", projectRoot, false))
+	for _, dir := range []string{"data/channels", "data/videos", "static/img/channels"} {
+		files, err := ioutil.ReadDir(path.Join(projectRoot, dir))
+		require.NoError(t, err)
+		assert.Empty(t, files, dir)
+	}
+}
+
+func TestRemoveChannelRollback(t *testing.T) {
+	projectRoot := writeProject(t, map[string]string{
+		"data/channels/anarchopac.yml":           "name: anarchopac\nslug: anarchopac\n",
+		"data/videos/anarchopac/5sd9Wd6R_Ms.yml": "id: 5sd9Wd6R_Ms\nchannel: anarchopac\n",
+		"static/img/channels/anarchopac.jpg":     "jpeg",
+		// Left behind by an earlier failure, the videos folder can't be moved
+		// aside onto it
+		"data/videos/.anarchopac.old/leftover.yml": "id: leftover\n",
+	})
+	defer os.RemoveAll(projectRoot)
+
+	assert.Error(t, RemoveChannel("anarchopac", projectRoot, false))
+	for _, name := range []string{
+		"data/channels/anarchopac.yml",
+		"data/videos/anarchopac/5sd9Wd6R_Ms.yml",
+		"static/img/channels/anarchopac.jpg",
+	} {
+		_, err := os.Stat(path.Join(projectRoot, name))
+		assert.NoError(t, err, name)
+	}
+}
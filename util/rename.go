@@ -0,0 +1,107 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// RenameChannel changes the slug of a channel, moving its file, videos folder
+// and profile image to the new slug and pointing its permalink and each of its
+// videos at it. With keepAlias the old permalink is kept in the channel's
+// aliases. A channel that other data files still refer to isn't renamed. If
+// any step fails, the steps already taken are undone.
+func RenameChannel(oldSlug, newSlug, projectRoot string, keepAlias bool) error {
+	if err := CheckSlug(newSlug); err != nil {
+		return err
+	}
+
+	dataDir := path.Join(projectRoot, "/data/channels")
+	channels, errs := LoadChannels(dataDir)
+	if len(errs) > 0 {
+		return LoadErrors(errs)
+	}
+
+	channel, ok := channels.Find(oldSlug)
+	if !ok {
+		return fmt.Errorf("couldn't find channel with slug '%s'", oldSlug)
+	}
+	if channels.Contains(newSlug) {
+		return fmt.Errorf("channel with slug '%s' already exists", newSlug)
+	}
+
+	// Like remove, a channel other data still refers to is left alone, so
+	// nothing ends up pointing at a slug that no longer exists
+	references, err := FindReferences(oldSlug, projectRoot)
+	if err != nil {
+		return err
+	}
+	if len(references) > 0 {
+		return fmt.Errorf("'%s' is still referenced by:\n  %s", oldSlug, strings.Join(references, "\n  "))
+	}
+
+	oldFile, newFile := channel.file, ChannelFilePath(newSlug, dataDir)
+	oldVideos, newVideos := ChannelVideoFolder(oldSlug, projectRoot), ChannelVideoFolder(newSlug, projectRoot)
+	oldImage, newImage := ChannelImagePath(oldSlug, projectRoot), ChannelImagePath(newSlug, projectRoot)
+	for _, target := range []string{newFile, newVideos, newImage} {
+		if _, err := os.Stat(target); err == nil {
+			return fmt.Errorf("'%s' already exists", target)
+		}
+	}
+
+	oldPermalink := channel.Permalink
+	if oldPermalink == "" {
+		oldPermalink = oldSlug
+	}
+	channel.Slug = newSlug
+	channel.Permalink = newSlug
+	if keepAlias && oldPermalink != newSlug {
+		channel.Aliases = append(channel.Aliases, oldPermalink)
+	}
+
+	channel.stampSchemaVersion()
+	data, err := yaml.Marshal(channel)
+	if err != nil {
+		return err
+	}
+
+	t := fileTransaction{}
+	defer t.cleanup()
+
+	if _, err := os.Stat(oldVideos); err == nil {
+		// The videos are rewritten into a staging folder first, so nothing
+		// changes until every one of them has been rewritten
+		staged := ChannelVideoFolder("."+newSlug+".staged", projectRoot)
+		t.temporary = append(t.temporary, staged)
+		if err := copyVideos(oldVideos, staged, newSlug); err != nil {
+			return t.rollback(err)
+		}
+
+		if err := t.discard(oldVideos); err != nil {
+			return t.rollback(err)
+		}
+		if err := t.move(staged, newVideos); err != nil {
+			return t.rollback(err)
+		}
+	}
+
+	if err := t.write(newFile, data); err != nil {
+		return t.rollback(err)
+	}
+
+	if _, err := os.Stat(oldImage); err == nil {
+		if err := t.move(oldImage, newImage); err != nil {
+			return t.rollback(err)
+		}
+	}
+
+	if err := t.discard(oldFile); err != nil {
+		return t.rollback(err)
+	}
+
+	t.commit()
+	return nil
+}
@@ -0,0 +1,120 @@
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestRenameChannel(t *testing.T) {
+	projectRoot := writeProject(t, map[string]string{
+		"data/channels/anarchopac.yml":           "name: anarchopac\nslug: anarchopac\npermalink: anarchopac\ntags: [breadtube]\n",
+		"data/videos/anarchopac/5sd9Wd6R_Ms.yml": "id: 5sd9Wd6R_Ms\ntitle: Election special\nchannel: anarchopac\n",
+		"static/img/channels/anarchopac.jpg":     "jpeg",
+	})
+	defer os.RemoveAll(projectRoot)
+
+	require.NoError(t, RenameChannel("anarchopac", "andrewism", projectRoot, true))
+
+	channels, errs := LoadChannels(path.Join(projectRoot, "data/channels"))
+	require.Empty(t, errs)
+	assert.False(t, channels.Contains("anarchopac"))
+	channel, ok := channels.Find("andrewism")
+	require.True(t, ok)
+	assert.Equal(t, "andrewism", channel.Permalink)
+	assert.Equal(t, []string{"anarchopac"}, channel.Aliases)
+	assert.Equal(t, []string{"breadtube"}, channel.Tags)
+
+	data, err := ioutil.ReadFile(path.Join(projectRoot, "data/videos/andrewism/5sd9Wd6R_Ms.yml"))
+	require.NoError(t, err)
+	assert.Equal(t, "id: 5sd9Wd6R_Ms\ntitle: Election special\nchannel: andrewism\n", string(data))
+
+	assert.FileExists(t, path.Join(projectRoot, "static/img/channels/andrewism.jpg"))
+	for _, old := range []string{"data/channels/anarchopac.yml", "data/videos/anarchopac", "data/videos/.anarchopac.old", "static/img/channels/anarchopac.jpg"} {
+		_, err := os.Stat(path.Join(projectRoot, old))
+		assert.True(t, os.IsNotExist(err), old)
+	}
+}
+
+func TestRenameChannel_RollsBack(t *testing.T) {
+	projectRoot := writeProject(t, map[string]string{
+		"data/channels/anarchopac.yml":           "name: anarchopac\nslug: anarchopac\n",
+		"data/videos/anarchopac/5sd9Wd6R_Ms.yml": "id: 5sd9Wd6R_Ms\nchannel: anarchopac\n",
+		"static/img/channels/anarchopac.jpg":     "jpeg",
+		// Moving the old videos aside fails on what is left of an earlier rename
+		"data/videos/.anarchopac.old/leftover.yml": "id: leftover\n",
+	})
+	defer os.RemoveAll(projectRoot)
+
+	assert.Error(t, RenameChannel("anarchopac", "andrewism", projectRoot, false))
+
+	channels, errs := LoadChannels(path.Join(projectRoot, "data/channels"))
+	require.Empty(t, errs)
+	assert.True(t, channels.Contains("anarchopac"))
+	assert.False(t, channels.Contains("andrewism"))
+	assert.FileExists(t, path.Join(projectRoot, "data/videos/anarchopac/5sd9Wd6R_Ms.yml"))
+	assert.FileExists(t, path.Join(projectRoot, "static/img/channels/anarchopac.jpg"))
+	assert.FileExists(t, path.Join(projectRoot, "data/videos/.anarchopac.old/leftover.yml"))
+	for _, renamed := range []string{"data/videos/andrewism", "data/videos/.andrewism.staged", "static/img/channels/andrewism.jpg"} {
+		_, err := os.Stat(path.Join(projectRoot, renamed))
+		assert.True(t, os.IsNotExist(err), renamed)
+	}
+}
+
+func TestChannelAliases(t *testing.T) {
+	channel := Channel{}
+	require.NoError(t, yaml.Unmarshal([]byte("slug: andrewism\naliases: anarchopac\n"), &channel))
+	assert.Equal(t, []string{"anarchopac"}, channel.Aliases)
+
+	data, err := yaml.Marshal(channel)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "aliases:\n- anarchopac\n")
+}
+
+func TestRenameChannelUnsafeSlug(t *testing.T) {
+	projectRoot := writeProject(t, map[string]string{
+		"data/channels/anarchopac.yml": "name: anarchopac\nslug: anarchopac\n",
+	})
+	defer os.RemoveAll(projectRoot)
+
+	for _, slug := range []string{"", "../anarchopac", "anarcho/pac", "..", ".anarchopac"} {
+		assert.Error(t, RenameChannel("anarchopac", slug, projectRoot, false), slug)
+	}
+	_, err := os.Stat(path.Join(projectRoot, "data/channels/anarchopac.yml"))
+	assert.NoError(t, err)
+}
+
+func TestRenameChannelFileNotNamedAfterSlug(t *testing.T) {
+	projectRoot := writeProject(t, map[string]string{
+		"data/channels/angie.yml": "name: Angie Speaks\nslug: angiespeaks\n",
+	})
+	defer os.RemoveAll(projectRoot)
+
+	require.NoError(t, RenameChannel("angiespeaks", "angie-speaks", projectRoot, false))
+
+	files, err := ioutil.ReadDir(path.Join(projectRoot, "data/channels"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "angie-speaks.yml", files[0].Name())
+}
+
+func TestRenameChannelReferenced(t *testing.T) {
+	projectRoot := writeProject(t, map[string]string{
+		"data/channels/anarchopac.yml": "name: anarchopac\nslug: anarchopac\n",
+		"data/playlists/elections.yml": "title: Elections\ncreator: anarchopac\n",
+	})
+	defer os.RemoveAll(projectRoot)
+
+	err := RenameChannel("anarchopac", "andrewism", projectRoot, true)
+	assert.EqualError(t, err, "'anarchopac' is still referenced by:\n  "+path.Join(projectRoot, "data/playlists/elections.yml"))
+
+	channels, errs := LoadChannels(path.Join(projectRoot, "data/channels"))
+	require.Empty(t, errs)
+	assert.True(t, channels.Contains("anarchopac"))
+	assert.False(t, channels.Contains("andrewism"))
+}
@@ -0,0 +1,259 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// schemaDraft is the version of JSON Schema the generated schemas follow
+const schemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// Schema is a JSON Schema document, covering the parts of the standard bake
+// generates and validates
+type Schema struct {
+	Draft                string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 SchemaTypes        `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	MinLength            int                `json:"minLength,omitempty"`
+	Minimum              *int               `json:"minimum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	// Values is the schema of every value of a map, which JSON Schema also
+	// calls additionalProperties
+	Values *Schema `json:"-"`
+}
+
+// SchemaTypes are the JSON types a value may have, written as a single string
+// when there is only one
+type SchemaTypes []string
+
+// MarshalJSON writes a single type as a string rather than a list
+func (t SchemaTypes) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// MarshalJSON writes the schema, with Values as additionalProperties
+func (s Schema) MarshalJSON() ([]byte, error) {
+	type schema Schema
+	if s.Values == nil {
+		return json.Marshal(schema(s))
+	}
+	return json.Marshal(struct {
+		schema
+		AdditionalProperties *Schema `json:"additionalProperties"`
+	}{schema(s), s.Values})
+}
+
+// SchemaExtender is implemented by types that add to the schema generated from
+// their fields, e.g. to give it a title or require some of the fields
+type SchemaExtender interface {
+	ExtendSchema(s *Schema)
+}
+
+// GenerateSchema builds the schema of a value's type from its fields, which
+// are named the way yaml names them
+func GenerateSchema(v interface{}) *Schema {
+	s := schemaFor(reflect.TypeOf(v))
+	s.Draft = schemaDraft
+	return s
+}
+
+var (
+	urlType      = reflect.TypeOf(URL{})
+	extenderType = reflect.TypeOf((*SchemaExtender)(nil)).Elem()
+)
+
+func schemaFor(t reflect.Type) *Schema {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	s := &Schema{}
+	switch {
+	case t == urlType:
+		s.Type = SchemaTypes{"string"}
+		s.Format = "uri"
+	case t.Kind() == reflect.String:
+		s.Type = SchemaTypes{"string"}
+	case t.Kind() == reflect.Bool:
+		s.Type = SchemaTypes{"boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Int64:
+		s.Type = SchemaTypes{"integer"}
+	case t.Kind() >= reflect.Uint && t.Kind() <= reflect.Uint64:
+		s.Type = SchemaTypes{"integer"}
+		zero := 0
+		s.Minimum = &zero
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		s.Type = SchemaTypes{"number"}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		s.Type = SchemaTypes{"array"}
+		s.Items = schemaFor(t.Elem())
+	case t.Kind() == reflect.Map:
+		s.Type = SchemaTypes{"object"}
+		s.Values = schemaFor(t.Elem())
+	case t.Kind() == reflect.Struct:
+		s.Type = SchemaTypes{"object"}
+		s.Properties = make(map[string]*Schema)
+		closed := false
+		s.AdditionalProperties = &closed
+
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			name := yamlFieldName(field)
+			if name == "-" {
+				continue
+			}
+			s.Properties[name] = schemaFor(field.Type)
+		}
+	}
+
+	if t.Implements(extenderType) {
+		reflect.Zero(t).Interface().(SchemaExtender).ExtendSchema(s)
+	}
+	return s
+}
+
+// yamlFieldName returns the key yaml uses for a struct field, the name from
+// its tag or else the field name in lowercase
+func yamlFieldName(field reflect.StructField) string {
+	tag := strings.Split(field.Tag.Get("yaml"), ",")[0]
+	if tag != "" {
+		return tag
+	}
+	return strings.ToLower(field.Name)
+}
+
+// SchemaError is a value that doesn't match its schema, Path says where the
+// value is, e.g. providers.youtube.url or tags[2]
+type SchemaError struct {
+	Path    string
+	Message string
+}
+
+func (e SchemaError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s %s", e.Path, e.Message)
+}
+
+// Validate checks a document decoded from YAML against the schema, returning
+// every value that doesn't match. Empty values, which bake ignores, are
+// treated as if they were missing.
+func (s *Schema) Validate(doc interface{}) []SchemaError {
+	var errs []SchemaError
+	s.validate("", doc, &errs)
+	return errs
+}
+
+func (s *Schema) validate(at string, value interface{}, errs *[]SchemaError) {
+	report := func(format string, args ...interface{}) {
+		*errs = append(*errs, SchemaError{Path: at, Message: fmt.Sprintf(format, args...)})
+	}
+
+	jsonType := schemaType(value)
+	if len(s.Type) > 0 && !s.allows(jsonType) {
+		report("should be %s, not %s", strings.Join(s.Type, " or "), jsonType)
+		return
+	}
+
+	switch v := value.(type) {
+	case string:
+		if len(v) < s.MinLength {
+			report("can't be empty")
+		}
+		if s.Format == "uri" {
+			if err := checkURL(v); err != nil {
+				report("'%s' %v", v, err)
+			}
+		}
+	case int:
+		if s.Minimum != nil && v < *s.Minimum {
+			report("should be at least %d", *s.Minimum)
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(fmt.Sprintf("%s[%d]", at, i), item, errs)
+			}
+		}
+	case map[interface{}]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, fmt.Sprint(key))
+		}
+		sort.Strings(keys)
+
+		for _, required := range s.Required {
+			if item, ok := v[required]; !ok || item == nil {
+				*errs = append(*errs, SchemaError{Path: joinPath(at, required), Message: "is missing"})
+			}
+		}
+
+		for _, key := range keys {
+			item := v[key]
+			if item == nil {
+				continue
+			}
+
+			if property, ok := s.Properties[key]; ok {
+				property.validate(joinPath(at, key), item, errs)
+			} else if s.Values != nil {
+				s.Values.validate(joinPath(at, key), item, errs)
+			} else if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				*errs = append(*errs, SchemaError{Path: joinPath(at, key), Message: "isn't a known field"})
+			}
+		}
+	}
+}
+
+func (s *Schema) allows(jsonType string) bool {
+	for _, t := range s.Type {
+		if t == jsonType || (t == "number" && jsonType == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaType names the JSON type of a value decoded from YAML
+func schemaType(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int, int64, uint64:
+		return "integer"
+	case float64:
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[interface{}]interface{}:
+		return "object"
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func joinPath(at, key string) string {
+	if at == "" {
+		return key
+	}
+	return at + "." + key
+}
@@ -0,0 +1,88 @@
+package util
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+type schemaTestItem struct {
+	ID     string `yaml:"id"`
+	Count  uint
+	Link   *URL
+	Labels map[string]string
+	hidden string
+}
+
+func (schemaTestItem) ExtendSchema(s *Schema) {
+	s.Required = []string{"id"}
+	s.Properties["id"].MinLength = 1
+}
+
+func TestGenerateSchema(t *testing.T) {
+	data, err := json.Marshal(GenerateSchema(schemaTestItem{}))
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"type": "object",
+		"properties": {
+			"id": {"type": "string", "minLength": 1},
+			"count": {"type": "integer", "minimum": 0},
+			"link": {"type": "string", "format": "uri"},
+			"labels": {"type": "object", "additionalProperties": {"type": "string"}}
+		},
+		"required": ["id"],
+		"additionalProperties": false
+	}`, string(data))
+}
+
+func TestSchemaValidate(t *testing.T) {
+	doc := map[interface{}]interface{}{}
+	require.NoError(t, yaml.Unmarshal([]byte(`
+count: -1
+link: ftp://example.com
+labels:
+  a: b
+  c: [d]
+other: true
+`), &doc))
+
+	assert.Equal(t, []SchemaError{
+		{Path: "id", Message: "is missing"},
+		{Path: "count", Message: "should be at least 0"},
+		{Path: "labels.c", Message: "should be string, not array"},
+		{Path: "link", Message: "'ftp://example.com' isn't an http or https URL"},
+		{Path: "other", Message: "isn't a known field"},
+	}, GenerateSchema(schemaTestItem{}).Validate(doc))
+}
+
+func TestChannelSchemaMatchesSavedChannels(t *testing.T) {
+	channel := Channel{
+		Name:          "ContraPoints",
+		Slug:          "contrapoints",
+		Aliases:       []string{"contra"},
+		Tags:          []string{"philosophy"},
+		SchemaVersion: ChannelSchemaVersion,
+		Providers: map[string]Provider{
+			"youtube": {
+				Name:        "ContraPoints",
+				Slug:        "UCNvsIonJdJ5E4EXMa65VYpA",
+				URL:         MustParseURL("https://www.youtube.com/channel/UCNvsIonJdJ5E4EXMa65VYpA"),
+				Description: "Philosophy",
+				Subscribers: 1000,
+				Videos:      []string{"5sd9Wd6R_Ms"},
+			},
+		},
+	}
+
+	data, err := yaml.Marshal(channel)
+	require.NoError(t, err)
+
+	doc := map[interface{}]interface{}{}
+	require.NoError(t, yaml.Unmarshal(data, &doc))
+	assert.Empty(t, GenerateSchema(Channel{}).Validate(doc))
+}
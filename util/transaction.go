@@ -0,0 +1,94 @@
+package util
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+)
+
+// fileTransaction keeps track of the changes made to files by a multi-step
+// operation, so they can all be undone if a later step fails
+type fileTransaction struct {
+	undo []func() error
+	// trash holds the files moved aside by discard, deleted on commit
+	trash []string
+	// temporary holds paths removed once the transaction is over, whether it
+	// was committed or not
+	temporary []string
+}
+
+// move renames from to to
+func (t *fileTransaction) move(from, to string) error {
+	log.Printf("Moving %s to %s\n", from, to)
+	if err := os.Rename(from, to); err != nil {
+		return err
+	}
+	t.undo = append(t.undo, func() error { return os.Rename(to, from) })
+	return nil
+}
+
+// write saves data to filePath, putting back what was there before on undo
+func (t *fileTransaction) write(filePath string, data []byte) error {
+	previous, readErr := ioutil.ReadFile(filePath)
+
+	log.Printf("Saving %s\n", filePath)
+	if err := WriteFile(filePath, data); err != nil {
+		return err
+	}
+
+	if readErr != nil {
+		t.undo = append(t.undo, func() error { return os.Remove(filePath) })
+	} else {
+		t.undo = append(t.undo, func() error { return WriteFile(filePath, previous) })
+	}
+	return nil
+}
+
+// mkdir creates a folder, removing it again on undo
+func (t *fileTransaction) mkdir(dir string) error {
+	if err := os.Mkdir(dir, 0755); err != nil {
+		return err
+	}
+	t.undo = append(t.undo, func() error { return os.Remove(dir) })
+	return nil
+}
+
+// discard removes a file or folder. It is only moved aside until the
+// transaction is committed, so it can be put back.
+func (t *fileTransaction) discard(filePath string) error {
+	aside := path.Join(path.Dir(filePath), "."+path.Base(filePath)+".old")
+	if err := t.move(filePath, aside); err != nil {
+		return err
+	}
+	t.trash = append(t.trash, aside)
+	return nil
+}
+
+// rollback undoes every change made so far, most recent first, and returns err
+func (t *fileTransaction) rollback(err error) error {
+	for i := len(t.undo) - 1; i >= 0; i-- {
+		if undoErr := t.undo[i](); undoErr != nil {
+			log.Printf("Failed to undo change: %v", undoErr)
+		}
+	}
+	t.undo = nil
+	t.trash = nil
+	return err
+}
+
+// commit keeps the changes and deletes everything that was discarded
+func (t *fileTransaction) commit() {
+	t.undo = nil
+	t.temporary = append(t.temporary, t.trash...)
+	t.trash = nil
+}
+
+// cleanup removes the temporary files, it is meant to be deferred
+func (t *fileTransaction) cleanup() {
+	for _, temporary := range t.temporary {
+		if err := os.RemoveAll(temporary); err != nil {
+			log.Printf("Failed to remove %s: %v", temporary, err)
+		}
+	}
+}
@@ -11,8 +11,11 @@ type URL url.URL
 // ParseURL is a wrapper function for url.Parse which returns the URL newtype
 func ParseURL(input string) (*URL, error) {
 	u, err := url.Parse(input)
+	if err != nil {
+		return nil, err
+	}
 	newURL := URL(*u)
-	return &newURL, err
+	return &newURL, nil
 }
 
 // MustParseURL is designed for parsing valid URLs, known to be valid at compile time
@@ -0,0 +1,294 @@
+package util
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Problem is something wrong with a data file
+type Problem struct {
+	File string
+	// Line is where in the file the problem is, zero when it is about the
+	// file as a whole
+	Line    int
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", p.File, p.Line, p.Message)
+	}
+	return fmt.Sprintf("%s: %s", p.File, p.Message)
+}
+
+// Validate checks every channel and video file in the project, returning all
+// the problems found, ordered by file and line. schemas holds the schema of
+// each kind of data file, keyed like Migrations, and needs every kind.
+func Validate(projectRoot string, schemas map[string]*Schema) ([]Problem, error) {
+	for _, kind := range Migrations.Kinds() {
+		if _, ok := schemas[kind]; !ok {
+			return nil, fmt.Errorf("no schema for %s", kind)
+		}
+	}
+	v := validator{projectRoot: projectRoot, schemas: schemas, slugs: make(map[string]string)}
+
+	if err := v.channels(); err != nil {
+		return nil, err
+	}
+	if err := v.videos(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(v.problems, func(i, j int) bool {
+		if v.problems[i].File != v.problems[j].File {
+			return v.problems[i].File < v.problems[j].File
+		}
+		return v.problems[i].Line < v.problems[j].Line
+	})
+	return v.problems, nil
+}
+
+// validator collects the problems found across every data file
+type validator struct {
+	projectRoot string
+	schemas     map[string]*Schema
+	// slugs maps each channel slug to the file that has it
+	slugs    map[string]string
+	problems []Problem
+}
+
+func (v *validator) report(file string, line int, format string, args ...interface{}) {
+	v.problems = append(v.problems, Problem{File: file, Line: line, Message: fmt.Sprintf(format, args...)})
+}
+
+// dataFile reports whether file in dir is a data file bake reads, which is a
+// .yml file like LoadChannels and the migrations read. Other YAML files are
+// reported, as bake would ignore them.
+func (v *validator) dataFile(dir string, file os.FileInfo) bool {
+	if file.IsDir() {
+		return false
+	}
+	ext := path.Ext(file.Name())
+	if ext != ".yml" && isYAMLFile(file.Name()) {
+		v.report(path.Join(dir, file.Name()), 0, "bake only reads .yml files, rename it to %s.yml", strings.TrimSuffix(file.Name(), ext))
+	}
+	return ext == ".yml"
+}
+
+func (v *validator) channels() error {
+	dataDir := path.Join(v.projectRoot, "/data/channels")
+	files, err := ioutil.ReadDir(dataDir)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if !v.dataFile(dataDir, file) {
+			continue
+		}
+		if err := v.channel(path.Join(dataDir, file.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *validator) channel(filePath string) error {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	raw := map[interface{}]interface{}{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		v.report(filePath, errorLine(err), "invalid YAML: %s", trimYAMLError(err))
+		return nil
+	}
+
+	schemaErrors := v.schema("channels", filePath, data, raw)
+
+	channel := Channel{}
+	if err := yaml.Unmarshal(data, &channel); err != nil {
+		// Values of the wrong type stop the channel from parsing, and have
+		// been reported along with their line already
+		if !schemaErrors {
+			v.report(filePath, 0, "invalid channel: %v", err)
+		}
+		return nil
+	}
+
+	slug := strings.TrimSuffix(path.Base(filePath), filepath.Ext(filePath))
+	if channel.Slug != "" && channel.Slug != slug {
+		v.report(filePath, findLine(data, "slug"), "slug '%s' doesn't match the file name", channel.Slug)
+	}
+
+	if channel.Slug != "" {
+		if other, ok := v.slugs[channel.Slug]; ok {
+			v.report(filePath, findLine(data, "slug"), "slug '%s' is used by %s as well", channel.Slug, other)
+		} else {
+			v.slugs[channel.Slug] = filePath
+		}
+	}
+
+	if channel.Slug != "" {
+		if _, err := os.Stat(ChannelImagePath(channel.Slug, v.projectRoot)); os.IsNotExist(err) {
+			v.report(filePath, 0, "missing profile image %s", strings.TrimPrefix(ChannelImagePath(channel.Slug, ""), "/"))
+		}
+	}
+
+	return nil
+}
+
+// schema checks a data file against the schema of its kind, reporting whether
+// there were any problems
+func (v *validator) schema(kind, filePath string, data []byte, doc interface{}) bool {
+	errs := v.schemas[kind].Validate(doc)
+	for _, err := range errs {
+		v.report(filePath, findLine(data, err.Path), "%v", err)
+	}
+	return len(errs) > 0
+}
+
+// checkURL makes sure a URL is a complete web address
+func checkURL(str string) error {
+	u, err := url.Parse(str)
+	if err != nil {
+		return fmt.Errorf("can't be parsed")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("isn't an http or https URL")
+	}
+	if u.Host == "" {
+		return fmt.Errorf("has no host")
+	}
+	return nil
+}
+
+func (v *validator) videos() error {
+	videosDir := path.Join(v.projectRoot, "/data/videos")
+	folders, err := ioutil.ReadDir(videosDir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, folder := range folders {
+		// Hidden folders are left behind by interrupted renames and merges
+		if !folder.IsDir() || strings.HasPrefix(folder.Name(), ".") {
+			continue
+		}
+
+		creator := folder.Name()
+		creatorDir := path.Join(videosDir, creator)
+		if _, ok := v.slugs[creator]; !ok {
+			v.report(creatorDir, 0, "videos for unknown creator '%s'", creator)
+		}
+
+		files, err := ioutil.ReadDir(creatorDir)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			if !v.dataFile(creatorDir, file) {
+				continue
+			}
+			if err := v.video(path.Join(creatorDir, file.Name()), creator); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (v *validator) video(filePath, creator string) error {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	raw := map[interface{}]interface{}{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		v.report(filePath, errorLine(err), "invalid YAML: %s", trimYAMLError(err))
+		return nil
+	}
+
+	v.schema("videos", filePath, data, raw)
+
+	if channel, ok := raw["channel"].(string); ok && channel != creator {
+		v.report(filePath, findLine(data, "channel"), "channel '%s' doesn't match the folder '%s'", channel, creator)
+	}
+	return nil
+}
+
+// findLine returns the line that sets the value at a schema error path such
+// as providers.youtube.url, or the closest line it can find, zero if none
+func findLine(data []byte, at string) int {
+	var keys []string
+	for _, key := range strings.Split(at, ".") {
+		// List items aren't looked for, only the key of the list
+		if i := strings.Index(key, "["); i >= 0 {
+			keys = append(keys, key[:i])
+			break
+		}
+		keys = append(keys, key)
+	}
+
+	// Each key is looked for among the lines indented like the first line
+	// of the block of the key before it
+	found, parent, indent := 0, -1, 0
+	for i, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		lineIndent := len(line) - len(trimmed)
+		if lineIndent <= parent {
+			// The block of the last key found ended without the next key
+			break
+		}
+		if indent < 0 {
+			indent = lineIndent
+		}
+		if lineIndent != indent || !strings.HasPrefix(trimmed, keys[0]+":") {
+			continue
+		}
+
+		found, parent, indent = i+1, lineIndent, -1
+		if keys = keys[1:]; len(keys) == 0 {
+			break
+		}
+	}
+	return found
+}
+
+var yamlErrorLine = regexp.MustCompile(`^yaml: line (\d+): `)
+
+// errorLine returns the line the YAML parser failed on, or zero
+func errorLine(err error) int {
+	match := yamlErrorLine.FindStringSubmatch(err.Error())
+	if match == nil {
+		return 0
+	}
+	line, _ := strconv.Atoi(match[1])
+	return line
+}
+
+// trimYAMLError removes the prefix and line number from a YAML parser error,
+// which are shown separately
+func trimYAMLError(err error) string {
+	msg := yamlErrorLine.ReplaceAllString(err.Error(), "")
+	return strings.TrimPrefix(msg, "yaml: ")
+}
@@ -0,0 +1,80 @@
+package util
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testVideo stands in for providers.Video, which this package can't import
+type testVideo struct {
+	ID      string `yaml:"id"`
+	Title   string
+	Channel string
+}
+
+func (testVideo) ExtendSchema(s *Schema) {
+	s.Required = []string{"id", "channel"}
+}
+
+var testSchemas = map[string]*Schema{
+	"channels": GenerateSchema(Channel{}),
+	"videos":   GenerateSchema(testVideo{}),
+}
+
+func TestValidate(t *testing.T) {
+	projectRoot := writeProject(t, map[string]string{
+		"data/channels/anarchopac.yml":             "name: anarchopac\nslug: anarchopac\ntags: [breadtube, 2020]\nproviders:\n  youtube:\n    url: https://www.youtube.com/user/anarchopac\n",
+		"data/channels/angiespeaks.yml":            "name: Angie Speaks\nslug: angie\nurl: www.youtube.com/angiespeaks\n",
+		"data/channels/copy.yml":                   "slug: anarchopac\nname: \"\"\n",
+		"data/channels/broken.yml":                 "name: Broken\nslug: [broken\n",
+		"data/channels/contra.yml":                 "name: ContraPoints\nslug: contra\nproviders:\n  patreon:\n    handle: contra\n    subscribers: -5\n  youtube:\n    url: youtube.com/contrapoints\ntags: [philosophy, [nested]]\n",
+		"data/channels/README.md":                  "not a channel",
+		"data/channels/foo.yaml":                   "name: Foo\nslug: foo\n",
+		"static/img/channels/anarchopac.jpg":       "jpeg",
+		"data/videos/anarchopac/5sd9Wd6R_Ms.yml":   "id: 5sd9Wd6R_Ms\nchannel: anarchopac\n",
+		"data/videos/anarchopac/Z1bGk2nQ3Lw.yml":   "id: Z1bGk2nQ3Lw\ntitle: Moved\nchannel: angie\n",
+		"data/videos/anarchopac/oLdDeLeTeD0.yml":   "id: oLdDeLeTeD0\nchannel: angie\nviews: 3\n",
+		"data/videos/anarchopac/xspEtjnSfQA.yml":   "id: xspEtjnSfQA\n",
+		"data/videos/anarchopac/aBcDeFgHiJk.yaml":  "id: aBcDeFgHiJk\nchannel: anarchopac\n",
+		"data/videos/contrapoints/qR7tY8uI9oP.yml": "id: qR7tY8uI9oP\nchannel: contrapoints\n",
+	})
+	defer os.RemoveAll(projectRoot)
+
+	problems, err := Validate(projectRoot, testSchemas)
+	require.NoError(t, err)
+
+	var found []string
+	for _, problem := range problems {
+		found = append(found, strings.Replace(problem.String(), projectRoot+"/", "", -1))
+	}
+	assert.Equal(t, []string{
+		"data/channels/angiespeaks.yml: missing profile image static/img/channels/angie.jpg",
+		"data/channels/angiespeaks.yml:2: slug 'angie' doesn't match the file name",
+		"data/channels/angiespeaks.yml:3: url 'www.youtube.com/angiespeaks' isn't an http or https URL",
+		"data/channels/broken.yml:2: invalid YAML: did not find expected ',' or ']'",
+		"data/channels/contra.yml: missing profile image static/img/channels/contra.jpg",
+		"data/channels/contra.yml:5: providers.patreon.handle isn't a known field",
+		"data/channels/contra.yml:6: providers.patreon.subscribers should be at least 0",
+		"data/channels/contra.yml:8: providers.youtube.url 'youtube.com/contrapoints' isn't an http or https URL",
+		"data/channels/contra.yml:9: tags[1] should be string or number or boolean, not array",
+		"data/channels/copy.yml:1: slug 'anarchopac' doesn't match the file name",
+		"data/channels/copy.yml:1: slug 'anarchopac' is used by data/channels/anarchopac.yml as well",
+		"data/channels/copy.yml:2: name can't be empty",
+		"data/channels/foo.yaml: bake only reads .yml files, rename it to foo.yml",
+		"data/videos/anarchopac/Z1bGk2nQ3Lw.yml:3: channel 'angie' doesn't match the folder 'anarchopac'",
+		"data/videos/anarchopac/aBcDeFgHiJk.yaml: bake only reads .yml files, rename it to aBcDeFgHiJk.yml",
+		"data/videos/anarchopac/oLdDeLeTeD0.yml:2: channel 'angie' doesn't match the folder 'anarchopac'",
+		"data/videos/anarchopac/oLdDeLeTeD0.yml:3: views isn't a known field",
+		"data/videos/anarchopac/xspEtjnSfQA.yml: channel is missing",
+		"data/videos/contrapoints: videos for unknown creator 'contrapoints'",
+	}, found)
+}
+
+func TestValidateNeedsEverySchema(t *testing.T) {
+	_, err := Validate("/nonexistent", map[string]*Schema{"channels": GenerateSchema(Channel{})})
+	assert.EqualError(t, err, "no schema for videos")
+}
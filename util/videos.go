@@ -7,20 +7,29 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+
+	yaml "gopkg.in/yaml.v2"
 )
 
+// ChannelVideoFolder returns the folder the videos of a creator are saved in
+func ChannelVideoFolder(slug string, projectRoot string) string {
+	return path.Join(projectRoot, fmt.Sprintf("/data/videos/%s", slug))
+}
+
 // CreateChannelVideoFolder creates an empty folder within the videos data folder
 func CreateChannelVideoFolder(channel *Channel, projectRoot string) error {
-	folder := path.Join(projectRoot, fmt.Sprintf("/data/videos/%s", channel.Slug))
+	folder := ChannelVideoFolder(channel.Slug, projectRoot)
 
 	// Make a video directory with a .gitignore
-	videoFolder := os.Mkdir(folder, os.ModePerm)
+	videoFolder := os.Mkdir(folder, 0755)
 	return videoFolder
 }
 
-// GetCreatorVideos returns a list of video IDs for a given creator
+// GetCreatorVideos returns a list of video IDs for a given creator, one for
+// each .yml file in their videos folder. Other files, such as a .gitignore,
+// aren't videos.
 func GetCreatorVideos(slug string, projectRoot string) ([]string, error) {
-	folder := path.Join(projectRoot, fmt.Sprintf("/data/videos/%s", slug))
+	folder := ChannelVideoFolder(slug, projectRoot)
 
 	// Using "channels" for consistency, but will change to creators in refactor.
 	videoDir, err := ioutil.ReadDir(folder)
@@ -31,11 +40,71 @@ func GetCreatorVideos(slug string, projectRoot string) ([]string, error) {
 	var videoIds []string
 
 	for _, file := range videoDir {
-		if !file.IsDir() {
-			fileName := file.Name()
-			videoIds = append(videoIds, strings.TrimSuffix(fileName, filepath.Ext(fileName)))
+		fileName := file.Name()
+		if !file.IsDir() && filepath.Ext(fileName) == ".yml" {
+			videoIds = append(videoIds, strings.TrimSuffix(fileName, ".yml"))
 		}
 	}
 
 	return videoIds, nil
 }
+
+// copyVideos copies every video file from one folder to another, setting the
+// channel of each video to slug
+func copyVideos(from, to, slug string) error {
+	files, err := ioutil.ReadDir(from)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(to, 0755); err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		filePath := path.Join(from, file.Name())
+		if file.IsDir() {
+			return fmt.Errorf("unexpected folder '%s' in videos", filePath)
+		}
+
+		data, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+
+		if isYAMLFile(filePath) {
+			data, err = setVideoChannel(data, slug)
+			if err != nil {
+				return fmt.Errorf("error rewriting '%s': %v", filePath, err)
+			}
+		}
+
+		if err := WriteFile(path.Join(to, file.Name()), data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setVideoChannel points a video file at the channel with slug, keeping the
+// rest of the file as it is
+func setVideoChannel(data []byte, slug string) ([]byte, error) {
+	video := yaml.MapSlice{}
+	if err := yaml.Unmarshal(data, &video); err != nil {
+		return nil, err
+	}
+
+	found := false
+	for i, item := range video {
+		if key, ok := item.Key.(string); ok && key == "channel" {
+			video[i].Value = slug
+			found = true
+		}
+	}
+	if !found {
+		video = append(video, yaml.MapItem{Key: "channel", Value: slug})
+	}
+
+	return yaml.Marshal(video)
+}
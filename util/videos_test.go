@@ -0,0 +1,25 @@
+package util
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetCreatorVideos(t *testing.T) {
+	projectRoot := writeProject(t, map[string]string{
+		"data/videos/anarchopac/5sd9Wd6R_Ms.yml":          "id: 5sd9Wd6R_Ms\nchannel: anarchopac\n",
+		"data/videos/anarchopac/Z1bGk2nQ3Lw.yml":          "id: Z1bGk2nQ3Lw\nchannel: anarchopac\n",
+		"data/videos/anarchopac/.gitignore":               "",
+		"data/videos/anarchopac/.xspEtjnSfQA.yml.123.tmp": "id: xspEtjnSfQA\n",
+		"data/videos/anarchopac/notes.txt":                "to import",
+		"data/videos/anarchopac/drafts/qR7tY8uI9oP.yml":   "id: qR7tY8uI9oP\n",
+	})
+	defer os.RemoveAll(projectRoot)
+
+	videos, err := GetCreatorVideos("anarchopac", projectRoot)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"5sd9Wd6R_Ms", "Z1bGk2nQ3Lw"}, videos)
+}